@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Redacted 审计记录中替代敏感参数值的文本
+const Redacted = "[REDACTED]"
+
+// Event 一次工具调用的审计记录
+type Event struct {
+	Time      time.Time              `json:"time"`                // 调用开始时间
+	Principal string                 `json:"principal,omitempty"` // 已认证调用方的标识，匿名调用时为空
+	Session   string                 `json:"session,omitempty"`   // 服务端签发的会话ID
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`           // 调用参数，敏感字段已替换为 Redacted
+	Status    string                 `json:"status"`              // ok 或 error
+	ErrorType string                 `json:"errorType,omitempty"` // 失败时的错误类型，与指标中的 type 标签一致
+	Duration  time.Duration          `json:"-"`                   // 调用耗时，JSON中以毫秒数 durationMs 输出
+	TraceID   string                 `json:"traceId,omitempty"`   // 开启链路追踪时的 trace ID
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，耗时以毫秒输出
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	return json.Marshal(struct {
+		event
+		DurationMs float64 `json:"durationMs"`
+	}{event(e), float64(e.Duration) / float64(time.Millisecond)})
+}
+
+// Sink 接收审计记录，在每次工具调用结束后同步调用，实现需要支持并发调用
+type Sink interface {
+	Record(ctx context.Context, event *Event) error
+}
+
+// SinkFunc 函数形式的审计输出
+type SinkFunc func(ctx context.Context, event *Event) error
+
+// Record 实现 Sink 接口
+func (f SinkFunc) Record(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// JSONLinesWriter 将审计记录按每行一个JSON对象写入 io.Writer
+type JSONLinesWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesWriter 创建写入 w 的审计输出
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{w: w}
+}
+
+// OpenJSONLinesFile 以追加方式打开审计日志文件，文件不存在时以 0600 权限创建
+func OpenJSONLinesFile(path string) (*JSONLinesWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log %s failed: %w", path, err)
+	}
+	return NewJSONLinesWriter(f), nil
+}
+
+// Record 实现 Sink 接口，每条记录以一次写入完成
+func (j *JSONLinesWriter) Record(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode audit event failed: %w", err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.w.Write(data); err != nil {
+		return fmt.Errorf("write audit event failed: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭底层的 io.Writer，不支持关闭时直接返回
+func (j *JSONLinesWriter) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if closer, ok := j.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventJSON(t *testing.T) {
+	event := Event{
+		Time:      time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+		Principal: "alice",
+		Tool:      "login",
+		Arguments: map[string]interface{}{"password": Redacted},
+		Status:    "ok",
+		Duration:  12500 * time.Microsecond,
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["durationMs"] != 12.5 {
+		t.Errorf("durationMs = %v, want 12.5", got["durationMs"])
+	}
+	if _, ok := got["Duration"]; ok {
+		t.Error("Duration is encoded in addition to durationMs")
+	}
+	for _, key := range []string{"session", "errorType", "traceId"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("empty %s is encoded", key)
+		}
+	}
+	if got["principal"] != "alice" || got["tool"] != "login" {
+		t.Errorf("event = %s", data)
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONLinesWriter(&buf)
+	for _, tool := range []string{"a", "b"} {
+		if err := sink.Record(context.Background(), &Event{Tool: tool, Status: "ok"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+	}
+}
+
+func TestOpenJSONLinesFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		sink, err := OpenJSONLinesFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Record(context.Background(), &Event{Tool: "login"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("file has %d lines, want 2 appended records", n)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode = %o, want 600", perm)
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+
+	"nacos-mcp-go/types"
+)
+
+// APIKeyHeader 携带API Key的请求头
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyAuthenticator 按静态API Key认证
+type apiKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]*Principal
+}
+
+// NewAPIKeyAuthenticator 创建静态API Key认证器，keys 为API Key到调用方的映射
+// 客户端通过 X-API-Key 请求头传递API Key
+func NewAPIKeyAuthenticator(keys map[string]*Principal) Authenticator {
+	a := &apiKeyAuthenticator{keys: make(map[[sha256.Size]byte]*Principal, len(keys))}
+	for key, principal := range keys {
+		// 按摘要查找，避免比较原始Key的耗时随公共前缀变化
+		a.keys[sha256.Sum256([]byte(key))] = principal
+	}
+	return a
+}
+
+// Authenticate 实现 Authenticator 接口
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	principal, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return principal, nil
+}
+
+// SecurityScheme 实现 SchemeProvider 接口
+func (a *apiKeyAuthenticator) SecurityScheme() types.SecurityScheme {
+	return types.SecurityScheme{ID: "apiKey", Type: "apiKey", In: "header", Name: APIKeyHeader}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"nacos-mcp-go/types"
+)
+
+var (
+	// ErrNoCredentials 请求中没有该认证器处理的凭证，继续尝试下一个认证器
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials 凭证无效或已过期
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnauthenticated 调用需要认证但请求未通过认证
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden 调用方没有访问工具的权限
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnavailable 认证依赖的服务暂时不可用，如令牌自省接口无法访问，客户端可稍后重试
+	ErrUnavailable = errors.New("authentication unavailable")
+)
+
+// Principal 已认证的调用方
+type Principal struct {
+	Subject string                 // 调用方标识，如API Key的名称或JWT的 sub
+	Scopes  []string               // 调用方具备的 scope
+	Claims  map[string]interface{} // JWT中的全部声明，API Key认证时为空
+}
+
+// HasScope 判断调用方是否具备指定的 scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator 从HTTP请求中认证调用方
+// 请求中没有其处理的凭证时返回 ErrNoCredentials，凭证无效时返回包装 ErrInvalidCredentials 的错误，
+// 无法完成校验时返回包装 ErrUnavailable 的错误
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// SchemeProvider 可描述自身认证方式的认证器，服务器注册到Nacos时据此声明认证要求
+type SchemeProvider interface {
+	SecurityScheme() types.SecurityScheme
+}
+
+// AuthenticatorFunc 函数形式的认证器
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate 实现 Authenticator 接口
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// principalKey 上下文中调用方的键
+type principalKey struct{}
+
+// NewContext 返回携带调用方的上下文
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext 读取上下文中已认证的调用方
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// BearerToken 读取 Authorization 请求头中的Bearer令牌
+func BearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
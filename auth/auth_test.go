@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// encodeSegment 将值编码为base64url形式的JSON片段
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signHS256 使用共享密钥签发 HS256 令牌
+func signHS256(t *testing.T, secret []byte, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 使用RSA私钥签发 RS256 令牌
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// rsaJWKS 返回只包含 key 公钥的JWKS
+func rsaJWKS(t *testing.T, key *rsa.PublicKey, kid string) *KeySet {
+	t.Helper()
+	doc := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":%q,"alg":"RS256","use":"sig","n":%q,"e":%q}]}`, kid,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	keys, err := ParseJWKS([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseJWKS() error = %v", err)
+	}
+	return keys
+}
+
+// bearerRequest 创建带有Bearer令牌的请求
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a := NewAPIKeyAuthenticator(map[string]*Principal{
+		"k-ci": {Subject: "ci", Scopes: []string{"users:read"}},
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no key: error = %v, want ErrNoCredentials", err)
+	}
+
+	r.Header.Set(APIKeyHeader, "k-unknown")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key: error = %v, want ErrInvalidCredentials", err)
+	}
+
+	r.Header.Set(APIKeyHeader, "k-ci")
+	principal, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatalf("valid key: error = %v", err)
+	}
+	if principal.Subject != "ci" || !principal.HasScope("users:read") || principal.HasScope("users:write") {
+		t.Errorf("principal = %+v, want ci with users:read", principal)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"bearer  abc ", "abc", true},
+		{"Basic abc", "", false},
+		{"Bearer", "", false},
+		{"Bearer  ", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.header)
+		token, ok := BearerToken(r)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("BearerToken(%q) = %q, %v, want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+		}
+	}
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	now := time.Now().Unix()
+	a := NewHMACAuthenticator(testSecret, WithIssuer("issuer"))
+
+	valid := signHS256(t, testSecret, map[string]interface{}{
+		"sub": "alice", "iss": "issuer", "exp": now + 60, "scope": "users:read users:write",
+	})
+	principal, err := a.Authenticate(bearerRequest(valid))
+	if err != nil {
+		t.Fatalf("valid token: error = %v", err)
+	}
+	if principal.Subject != "alice" || !reflect.DeepEqual(principal.Scopes, []string{"users:read", "users:write"}) {
+		t.Errorf("principal = %+v, want alice with users:read users:write", principal)
+	}
+
+	scp := signHS256(t, testSecret, map[string]interface{}{"sub": "bob", "iss": "issuer", "exp": now + 60, "scp": []string{"a", "b"}})
+	if principal, err := a.Authenticate(bearerRequest(scp)); err != nil || !reflect.DeepEqual(principal.Scopes, []string{"a", "b"}) {
+		t.Errorf("scp token: principal = %+v, error = %v, want scopes [a b]", principal, err)
+	}
+
+	invalid := map[string]string{
+		"bad signature": signHS256(t, []byte("another-secret-another-secret-00"), map[string]interface{}{"sub": "alice", "iss": "issuer"}),
+		"expired":       signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "iss": "issuer", "exp": now - 60}),
+		"not yet valid": signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "iss": "issuer", "nbf": now + 600}),
+		"wrong issuer":  signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "iss": "other"}),
+		"unsigned":      encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, map[string]string{"sub": "alice"}) + ".",
+		"malformed":     "not-a-jwt",
+	}
+	for name, token := range invalid {
+		if _, err := a.Authenticate(bearerRequest(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+
+	if _, err := a.Authenticate(bearerRequest("")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no token: error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestHMACAuthenticatorLeeway(t *testing.T) {
+	expired := signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "exp": time.Now().Unix() - 5})
+	if _, err := NewHMACAuthenticator(testSecret).Authenticate(bearerRequest(expired)); err == nil {
+		t.Error("expired token accepted without leeway")
+	}
+	if _, err := NewHMACAuthenticator(testSecret, WithLeeway(time.Minute)).Authenticate(bearerRequest(expired)); err != nil {
+		t.Errorf("token expired within leeway: error = %v", err)
+	}
+}
+
+func TestJWKSAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewJWKSAuthenticator(rsaJWKS(t, &key.PublicKey, "k1"))
+
+	token := signRS256(t, key, "k1", map[string]interface{}{"sub": "svc", "exp": time.Now().Unix() + 60})
+	principal, err := a.Authenticate(bearerRequest(token))
+	if err != nil {
+		t.Fatalf("valid token: error = %v", err)
+	}
+	if principal.Subject != "svc" {
+		t.Errorf("subject = %q, want svc", principal.Subject)
+	}
+
+	forged := signRS256(t, other, "k1", map[string]interface{}{"sub": "svc"})
+	if _, err := a.Authenticate(bearerRequest(forged)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("token signed with another key: error = %v, want ErrInvalidCredentials", err)
+	}
+
+	// 其他 kid 或 HMAC 令牌交给后续认证器处理
+	unknownKid := signRS256(t, key, "k2", map[string]interface{}{"sub": "svc"})
+	if _, err := a.Authenticate(bearerRequest(unknownKid)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unknown kid: error = %v, want ErrNoCredentials", err)
+	}
+	hmacToken := signHS256(t, testSecret, map[string]interface{}{"sub": "svc"})
+	if _, err := a.Authenticate(bearerRequest(hmacToken)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("HMAC token: error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestJWKSAuthenticatorEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ParseJWKS([]byte(fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"ed","x":%q}]}`,
+		base64.RawURLEncoding.EncodeToString(pub))))
+	if err != nil {
+		t.Fatalf("ParseJWKS() error = %v", err)
+	}
+
+	signed := encodeSegment(t, map[string]string{"alg": "EdDSA", "kid": "ed"}) + "." + encodeSegment(t, map[string]interface{}{"sub": "edge", "exp": time.Now().Unix() + 60})
+	token := signed + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, []byte(signed)))
+	principal, err := NewJWKSAuthenticator(keys).Authenticate(bearerRequest(token))
+	if err != nil || principal.Subject != "edge" {
+		t.Fatalf("EdDSA token: principal = %+v, error = %v", principal, err)
+	}
+}
+
+func TestParseJWKSRejectsWeakRSAKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"weak","n":%q,"e":"AQAB"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()))
+	if _, err := ParseJWKS([]byte(doc)); err == nil {
+		t.Error("ParseJWKS() accepted a 1024-bit RSA key")
+	}
+}
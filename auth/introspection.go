@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"nacos-mcp-go/types"
+)
+
+// introspectionAuthenticator 通过授权服务器的令牌自省接口（RFC 7662）校验不透明的访问令牌
+type introspectionAuthenticator struct {
+	tokenConfig
+	endpoint string
+}
+
+// NewIntrospectionAuthenticator 创建令牌自省认证器，endpoint 为授权服务器的自省接口地址
+// 授权服务器要求客户端认证时使用 WithClientCredentials 设置凭证
+func NewIntrospectionAuthenticator(endpoint string, opts ...TokenOption) Authenticator {
+	return &introspectionAuthenticator{
+		tokenConfig: newTokenConfig(opts),
+		endpoint:    endpoint,
+	}
+}
+
+// Authenticate 实现 Authenticator 接口
+func (a *introspectionAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.introspect(r, token)
+	if err != nil {
+		return nil, err
+	}
+	if active, _ := claims["active"].(bool); !active {
+		return nil, fmt.Errorf("%w: token is not active", ErrInvalidCredentials)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return principalFromClaims(claims), nil
+}
+
+// BindAudience 实现 AudienceBinder 接口
+func (a *introspectionAuthenticator) BindAudience(audience string) Authenticator {
+	if a.audience != "" {
+		return a
+	}
+	bound := *a
+	bound.audience = audience
+	return &bound
+}
+
+// SecurityScheme 实现 SchemeProvider 接口
+func (a *introspectionAuthenticator) SecurityScheme() types.SecurityScheme {
+	return bearerScheme
+}
+
+// introspect 调用自省接口，返回令牌的声明
+func (a *introspectionAuthenticator) introspect(r *http.Request, token string) (map[string]interface{}, error) {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, a.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create introspection request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if a.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+
+	// 自省接口无法访问时令牌的有效性未知，返回 ErrUnavailable 而不是拒绝令牌
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: token introspection failed: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: read introspection response failed: %v", ErrUnavailable, err)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: token introspection failed with status %d", ErrUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token introspection failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var claims map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("parse introspection response failed: %w", err)
+	}
+	return claims, nil
+}
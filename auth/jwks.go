@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// minRSABits 接受的最小RSA密钥长度
+const minRSABits = 2048
+
+// KeySet JWKS中用于校验签名的公钥
+type KeySet struct {
+	keys []webKey
+}
+
+// webKey 解析后的单个公钥
+type webKey struct {
+	kid string
+	alg string
+	key interface{} // *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey
+}
+
+// rawWebKey JWKS中的JSON Web Key
+type rawWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS 从本地文件加载JWKS
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS %s failed: %w", path, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse JWKS %s failed: %w", path, err)
+	}
+	return keys, nil
+}
+
+// ParseJWKS 解析JWKS，支持 RSA、EC(P-256/P-384/P-521) 和 OKP(Ed25519) 公钥
+// 用于加密(use=enc)的密钥以及对称密钥被忽略
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []rawWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{}
+	for i, raw := range doc.Keys {
+		if raw.Use == "enc" || raw.Kty == "oct" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			name := raw.Kid
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			return nil, fmt.Errorf("key %s: %w", name, err)
+		}
+		set.keys = append(set.keys, webKey{kid: raw.Kid, alg: raw.Alg, key: key})
+	}
+	if len(set.keys) == 0 {
+		return nil, fmt.Errorf("no signing keys")
+	}
+	return set, nil
+}
+
+// publicKey 将JSON Web Key转换为公钥
+func (k *rawWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		if n.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key is %d bits, at least %d bits are required", n.BitLen(), minRSABits)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		var exchange ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, exchange = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, exchange = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, exchange = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y, "y")
+		if err != nil {
+			return nil, err
+		}
+		// 按未压缩格式编码后由 crypto/ecdh 校验点是否在曲线上
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x.Bytes()) > size || len(y.Bytes()) > size {
+			return nil, fmt.Errorf("invalid EC point")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		x.FillBytes(point[1 : 1+size])
+		y.FillBytes(point[1+size:])
+		if _, err := exchange.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt 解码base64url编码的大整数
+func decodeBigInt(value, name string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// candidates 返回可校验给定算法和 kid 的公钥，令牌指定了 kid 时只匹配该密钥
+func (s *KeySet) candidates(alg, kid string) ([]interface{}, error) {
+	if strings.HasPrefix(alg, "HS") {
+		return nil, fmt.Errorf("algorithm %s is not allowed for public keys", alg)
+	}
+
+	var keys []interface{}
+	for _, k := range s.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		keys = append(keys, k.key)
+	}
+	if len(keys) == 0 {
+		if kid != "" {
+			return nil, fmt.Errorf("no key with id %q for algorithm %s", kid, alg)
+		}
+		return nil, fmt.Errorf("no key for algorithm %s", alg)
+	}
+	return keys, nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"nacos-mcp-go/types"
+)
+
+// errNoKey 校验器没有可用于该令牌的密钥，令牌可能由其他认证器处理
+var errNoKey = errors.New("no applicable key")
+
+// jwtVerifier 校验Bearer令牌中的JWT
+type jwtVerifier struct {
+	tokenConfig
+	keys func(alg, kid string) ([]interface{}, error) // 返回可用于校验签名的候选密钥
+}
+
+// NewHMACAuthenticator 创建使用共享密钥校验 HS256/HS384/HS512 签名令牌的认证器
+func NewHMACAuthenticator(secret []byte, opts ...TokenOption) Authenticator {
+	key := append([]byte(nil), secret...)
+	return newJWTVerifier(func(alg, kid string) ([]interface{}, error) {
+		if !strings.HasPrefix(alg, "HS") {
+			return nil, fmt.Errorf("algorithm %s is not allowed, only HMAC algorithms are accepted", alg)
+		}
+		return []interface{}{key}, nil
+	}, opts...)
+}
+
+// NewJWKSAuthenticator 创建使用JWKS中的公钥校验令牌签名的认证器
+func NewJWKSAuthenticator(keys *KeySet, opts ...TokenOption) Authenticator {
+	return newJWTVerifier(keys.candidates, opts...)
+}
+
+// newJWTVerifier 创建JWT校验器，默认拒绝没有 exp 的令牌
+func newJWTVerifier(keys func(alg, kid string) ([]interface{}, error), opts ...TokenOption) *jwtVerifier {
+	opts = append([]TokenOption{WithRequireExpiry(true)}, opts...)
+	return &jwtVerifier{
+		tokenConfig: newTokenConfig(opts),
+		keys:        keys,
+	}
+}
+
+// SecurityScheme 实现 SchemeProvider 接口
+func (v *jwtVerifier) SecurityScheme() types.SecurityScheme {
+	return bearerScheme
+}
+
+// BindAudience 实现 AudienceBinder 接口
+func (v *jwtVerifier) BindAudience(audience string) Authenticator {
+	if v.audience != "" {
+		return v
+	}
+	bound := *v
+	bound.audience = audience
+	return &bound
+}
+
+// Authenticate 实现 Authenticator 接口
+func (v *jwtVerifier) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	claims, err := v.verify(token)
+	if errors.Is(err, errNoKey) {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return principalFromClaims(claims), nil
+}
+
+// verify 校验令牌签名和声明，返回令牌中的声明
+func (v *jwtVerifier) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	if header.Alg == "" || header.Alg == "none" {
+		return nil, fmt.Errorf("unsigned token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+	keys, err := v.keys(header.Alg, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoKey, err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if err = verifySignature(header.Alg, key, signed, signature); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// decodeSegment 解码令牌中base64url编码的JSON片段
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// signatureHash 返回签名算法使用的摘要算法
+func signatureHash(alg string) (crypto.Hash, error) {
+	if alg == "EdDSA" {
+		return 0, nil
+	}
+	if len(alg) != 5 {
+		return 0, fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported algorithm %s", alg)
+}
+
+// verifySignature 按算法校验签名，密钥类型必须与算法匹配
+func verifySignature(alg string, key interface{}, signed, signature []byte) error {
+	hash, err := signatureHash(alg)
+	if err != nil {
+		return err
+	}
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	invalid := fmt.Errorf("invalid signature")
+	mismatch := fmt.Errorf("key type %T cannot verify %s signatures", key, alg)
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return mismatch
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return invalid
+		}
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return mismatch
+		}
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+		if err != nil {
+			return invalid
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().BitSize != curveBits(alg) {
+			return mismatch
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return invalid
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return invalid
+		}
+	case "Ed":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return mismatch
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return invalid
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %s", alg)
+	}
+	return nil
+}
+
+// curveBits 返回ECDSA算法要求的曲线位数，ES512 使用 P-521
+func curveBits(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	case "ES512":
+		return 521
+	}
+	return 0
+}
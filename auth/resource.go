@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+
+	"nacos-mcp-go/types"
+)
+
+// ResourceMetadataPath 受保护资源元数据的 well-known 路径（RFC 9728）
+const ResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// ResourceMetadata OAuth 2.0 受保护资源元数据，MCP客户端据此找到签发访问令牌的授权服务器
+type ResourceMetadata struct {
+	Resource               string   `json:"resource"`                           // 资源标识，即MCP接口的地址，访问令牌的 aud 应包含该值
+	AuthorizationServers   []string `json:"authorization_servers"`              // 授权服务器的issuer地址
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`         // 资源使用的 scope
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"` // 令牌的传递方式，默认 header
+	ResourceName           string   `json:"resource_name,omitempty"`            // 展示给用户的资源名称
+	ResourceDocumentation  string   `json:"resource_documentation,omitempty"`   // 资源文档地址
+}
+
+// MetadataPath 返回元数据的路径，资源地址带有路径时将其追加在 well-known 路径之后
+func (m *ResourceMetadata) MetadataPath() string {
+	u, err := url.Parse(m.Resource)
+	if err != nil {
+		return ResourceMetadataPath
+	}
+	return ResourceMetadataPath + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// MetadataURL 返回元数据的完整地址，用于 WWW-Authenticate 质询中的 resource_metadata 参数
+func (m *ResourceMetadata) MetadataURL() string {
+	u, err := url.Parse(m.Resource)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return m.MetadataPath()
+	}
+	return u.Scheme + "://" + u.Host + m.MetadataPath()
+}
+
+// SecurityScheme 返回元数据对应的 OAuth 认证方式
+func (m *ResourceMetadata) SecurityScheme() types.SecurityScheme {
+	return types.SecurityScheme{
+		ID:                   "oauth2",
+		Type:                 "oauth2",
+		ResourceMetadata:     m.MetadataURL(),
+		AuthorizationServers: m.AuthorizationServers,
+	}
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testResource = "https://mcp.example.com/mcp"
+
+func TestJWTAudience(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Unix() + 60
+
+	tests := []struct {
+		name   string
+		a      Authenticator
+		sign   func(claims map[string]interface{}) string
+		forged func(claims map[string]interface{}) string
+	}{
+		{
+			name: "HMAC",
+			a:    NewHMACAuthenticator(testSecret, WithAudience(testResource)),
+			sign: func(c map[string]interface{}) string { return signHS256(t, testSecret, c) },
+			forged: func(c map[string]interface{}) string {
+				return signHS256(t, []byte("forged-secret-forged-secret-0000"), c)
+			},
+		},
+		{
+			name:   "JWKS",
+			a:      NewJWKSAuthenticator(rsaJWKS(t, &key.PublicKey, "k1"), WithAudience(testResource)),
+			sign:   func(c map[string]interface{}) string { return signRS256(t, key, "k1", c) },
+			forged: func(c map[string]interface{}) string { return signRS256(t, other, "k1", c) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted := map[string]string{
+				"audience string": tt.sign(map[string]interface{}{"sub": "alice", "aud": testResource, "exp": exp}),
+				"audience array":  tt.sign(map[string]interface{}{"sub": "alice", "aud": []string{"other", testResource}, "exp": exp}),
+			}
+			for name, token := range accepted {
+				if _, err := tt.a.Authenticate(bearerRequest(token)); err != nil {
+					t.Errorf("%s: error = %v", name, err)
+				}
+			}
+
+			rejected := map[string]string{
+				"wrong audience":   tt.sign(map[string]interface{}{"sub": "alice", "aud": "https://other.example.com/mcp", "exp": exp}),
+				"missing audience": tt.sign(map[string]interface{}{"sub": "alice", "exp": exp}),
+				"expired":          tt.sign(map[string]interface{}{"sub": "alice", "aud": testResource, "exp": time.Now().Unix() - 60}),
+				"bad signature":    tt.forged(map[string]interface{}{"sub": "alice", "aud": testResource, "exp": exp}),
+			}
+			for name, token := range rejected {
+				if _, err := tt.a.Authenticate(bearerRequest(token)); !errors.Is(err, ErrInvalidCredentials) {
+					t.Errorf("%s: error = %v, want ErrInvalidCredentials", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBindAudience(t *testing.T) {
+	exp := time.Now().Unix() + 60
+	wrong := signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "aud": "https://other.example.com/mcp", "exp": exp})
+	right := signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "aud": testResource, "exp": exp})
+
+	unbound := NewHMACAuthenticator(testSecret)
+	if _, err := unbound.Authenticate(bearerRequest(wrong)); err != nil {
+		t.Fatalf("authenticator without audience: error = %v", err)
+	}
+
+	bound := unbound.(AudienceBinder).BindAudience(testResource)
+	if _, err := bound.Authenticate(bearerRequest(wrong)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("bound authenticator, wrong audience: error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := bound.Authenticate(bearerRequest(right)); err != nil {
+		t.Errorf("bound authenticator, resource audience: error = %v", err)
+	}
+	// 绑定返回副本，不修改原认证器
+	if _, err := unbound.Authenticate(bearerRequest(wrong)); err != nil {
+		t.Errorf("original authenticator changed by BindAudience: error = %v", err)
+	}
+
+	explicit := NewHMACAuthenticator(testSecret, WithAudience("api://custom")).(AudienceBinder).BindAudience(testResource)
+	custom := signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "aud": "api://custom", "exp": exp})
+	if _, err := explicit.Authenticate(bearerRequest(custom)); err != nil {
+		t.Errorf("explicit audience replaced by BindAudience: error = %v", err)
+	}
+}
+
+// newIntrospectionServer 返回按令牌给出自省结果的授权服务器
+func newIntrospectionServer(t *testing.T, results map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "mcp" || secret != "s3cret" {
+			http.Error(w, "unauthorized client", http.StatusUnauthorized)
+			return
+		}
+		result, ok := results[r.PostFormValue("token")]
+		if !ok {
+			result = map[string]interface{}{"active": false}
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestIntrospectionAuthenticator(t *testing.T) {
+	now := time.Now().Unix()
+	ts := newIntrospectionServer(t, map[string]map[string]interface{}{
+		"valid":    {"active": true, "sub": "alice", "aud": testResource, "scope": "users:read", "exp": now + 60},
+		"other":    {"active": true, "sub": "alice", "aud": "https://other.example.com/mcp", "exp": now + 60},
+		"expired":  {"active": true, "sub": "alice", "aud": testResource, "exp": now - 60},
+		"inactive": {"active": false},
+	})
+	a := NewIntrospectionAuthenticator(ts.URL, WithClientCredentials("mcp", "s3cret")).(AudienceBinder).BindAudience(testResource)
+
+	principal, err := a.Authenticate(bearerRequest("valid"))
+	if err != nil {
+		t.Fatalf("valid token: error = %v", err)
+	}
+	if principal.Subject != "alice" || !principal.HasScope("users:read") {
+		t.Errorf("principal = %+v, want alice with users:read", principal)
+	}
+
+	for _, token := range []string{"other", "expired", "inactive", "unknown"} {
+		if _, err := a.Authenticate(bearerRequest(token)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s token: error = %v, want ErrInvalidCredentials", token, err)
+		}
+	}
+
+	wrongClient := NewIntrospectionAuthenticator(ts.URL, WithClientCredentials("mcp", "wrong"))
+	if _, err := wrongClient.Authenticate(bearerRequest("valid")); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("introspection with wrong client credentials: error = %v, want a rejection", err)
+	}
+}
+
+func TestIntrospectionUnavailable(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for name, endpoint := range map[string]string{"5xx": failing.URL, "transport": closed.URL} {
+		_, err := NewIntrospectionAuthenticator(endpoint).Authenticate(bearerRequest("valid"))
+		if !errors.Is(err, ErrUnavailable) || errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: error = %v, want ErrUnavailable", name, err)
+		}
+	}
+}
+
+func TestRequireExpiry(t *testing.T) {
+	noExp := signHS256(t, testSecret, map[string]interface{}{"sub": "alice"})
+	if _, err := NewHMACAuthenticator(testSecret).Authenticate(bearerRequest(noExp)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("JWT without exp: error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := NewHMACAuthenticator(testSecret, WithRequireExpiry(false)).Authenticate(bearerRequest(noExp)); err != nil {
+		t.Errorf("JWT without exp, expiry not required: error = %v", err)
+	}
+
+	ts := newIntrospectionServer(t, map[string]map[string]interface{}{
+		"opaque": {"active": true, "sub": "alice"},
+	})
+	if _, err := NewIntrospectionAuthenticator(ts.URL, WithClientCredentials("mcp", "s3cret")).Authenticate(bearerRequest("opaque")); err != nil {
+		t.Errorf("introspected token without exp: error = %v", err)
+	}
+	strict := NewIntrospectionAuthenticator(ts.URL, WithClientCredentials("mcp", "s3cret"), WithRequireExpiry(true))
+	if _, err := strict.Authenticate(bearerRequest("opaque")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("introspected token without exp, expiry required: error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestResourceMetadataURL(t *testing.T) {
+	tests := []struct {
+		resource string
+		path     string
+		url      string
+	}{
+		{"https://mcp.example.com/mcp", "/.well-known/oauth-protected-resource/mcp", "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"},
+		{"https://mcp.example.com/", "/.well-known/oauth-protected-resource", "https://mcp.example.com/.well-known/oauth-protected-resource"},
+		{"http://127.0.0.1:8080/tenants/a/mcp", "/.well-known/oauth-protected-resource/tenants/a/mcp", "http://127.0.0.1:8080/.well-known/oauth-protected-resource/tenants/a/mcp"},
+	}
+	for _, tt := range tests {
+		m := &ResourceMetadata{Resource: tt.resource}
+		if got := m.MetadataPath(); got != tt.path {
+			t.Errorf("MetadataPath(%q) = %q, want %q", tt.resource, got, tt.path)
+		}
+		if got := m.MetadataURL(); got != tt.url {
+			t.Errorf("MetadataURL(%q) = %q, want %q", tt.resource, got, tt.url)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"nacos-mcp-go/types"
+)
+
+// bearerScheme 通过 Authorization: Bearer 传递令牌的认证方式
+var bearerScheme = types.SecurityScheme{ID: "bearer", Type: "http", Scheme: "bearer"}
+
+// TokenOption 令牌校验选项，用于JWT和令牌自省认证器
+type TokenOption func(*tokenConfig)
+
+// WithIssuer 要求令牌的 iss 等于指定值
+func WithIssuer(issuer string) TokenOption {
+	return func(c *tokenConfig) {
+		c.issuer = issuer
+	}
+}
+
+// WithAudience 要求令牌的 aud 包含指定值，通常为MCP服务器的资源标识
+// 服务器配置了受保护资源元数据时，未设置受众的认证器默认以资源标识作为受众
+func WithAudience(audience string) TokenOption {
+	return func(c *tokenConfig) {
+		c.audience = audience
+	}
+}
+
+// WithLeeway 设置校验 exp、nbf 时允许的时钟偏差
+func WithLeeway(leeway time.Duration) TokenOption {
+	return func(c *tokenConfig) {
+		c.leeway = leeway
+	}
+}
+
+// WithRequireExpiry 设置是否拒绝没有 exp 声明的令牌
+// JWT认证器默认要求 exp；令牌自省认证器默认不要求，令牌是否有效由自省结果中的 active 决定
+func WithRequireExpiry(require bool) TokenOption {
+	return func(c *tokenConfig) {
+		c.requireExpiry = require
+	}
+}
+
+// WithClientCredentials 设置调用令牌自省接口时使用的客户端凭证，仅用于令牌自省认证器
+func WithClientCredentials(clientID, clientSecret string) TokenOption {
+	return func(c *tokenConfig) {
+		c.clientID = clientID
+		c.clientSecret = clientSecret
+	}
+}
+
+// WithHTTPClient 设置调用令牌自省接口的HTTP客户端，仅用于令牌自省认证器
+func WithHTTPClient(client *http.Client) TokenOption {
+	return func(c *tokenConfig) {
+		c.client = client
+	}
+}
+
+// AudienceBinder 校验令牌受众的认证器
+// 服务器作为OAuth资源服务器时，将未设置受众的认证器绑定到自身的资源标识，拒绝为其他资源签发的令牌
+type AudienceBinder interface {
+	Authenticator
+	// BindAudience 返回以 audience 作为受众的认证器，已通过 WithAudience 设置受众时返回自身
+	BindAudience(audience string) Authenticator
+}
+
+// tokenConfig 令牌校验配置
+type tokenConfig struct {
+	issuer        string
+	audience      string
+	leeway        time.Duration
+	requireExpiry bool
+	clientID      string
+	clientSecret  string
+	client        *http.Client
+	now           func() time.Time
+}
+
+// newTokenConfig 创建令牌校验配置
+func newTokenConfig(opts []TokenOption) tokenConfig {
+	c := tokenConfig{
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// validateClaims 校验 exp、nbf、iss、aud 声明
+func (c *tokenConfig) validateClaims(claims map[string]interface{}) error {
+	now := c.now()
+	if exp, ok, err := timeClaim(claims, "exp"); err != nil {
+		return err
+	} else if !ok && c.requireExpiry {
+		return fmt.Errorf("token has no expiration")
+	} else if ok && !now.Before(exp.Add(c.leeway)) {
+		return fmt.Errorf("token is expired")
+	}
+	if nbf, ok, err := timeClaim(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(c.leeway).Before(nbf) {
+		return fmt.Errorf("token is not valid yet")
+	}
+
+	if c.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != c.issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if c.audience != "" && !containsClaim(claims["aud"], c.audience) {
+		return fmt.Errorf("token is not issued for audience %q", c.audience)
+	}
+	return nil
+}
+
+// timeClaim 读取以秒为单位的时间声明
+func timeClaim(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("claim %s must be a number", name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("claim %s must be a number", name)
+	}
+	whole := math.Floor(seconds)
+	return time.Unix(int64(whole), int64((seconds-whole)*float64(time.Second))), true, nil
+}
+
+// containsClaim 判断字符串或字符串数组声明是否包含指定值
+func containsClaim(claim interface{}, value string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == value
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// principalFromClaims 由令牌声明构建调用方，scope 取自空格分隔的 scope 或数组形式的 scp
+func principalFromClaims(claims map[string]interface{}) *Principal {
+	principal := &Principal{Claims: claims}
+	principal.Subject, _ = claims["sub"].(string)
+
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	}
+	if principal.Scopes == nil {
+		switch scp := claims["scp"].(type) {
+		case string:
+			principal.Scopes = strings.Fields(scp)
+		case []interface{}:
+			for _, item := range scp {
+				if s, ok := item.(string); ok {
+					principal.Scopes = append(principal.Scopes, s)
+				}
+			}
+		}
+	}
+	return principal
+}
@@ -0,0 +1,120 @@
+package nacosmcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/handler"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signToken 使用 testSecret 签发 HS256 令牌，claims 中没有 exp 时一分钟后过期
+func signToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+	}
+	segment := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(map[string]string{"alg": "HS256"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// serveMCP 使用服务器配置的处理器处理 tools/list 请求
+func serveMCP(t *testing.T, s *Server, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	handler.NewHTTPHandler(s, s.handlerOptions()...).RegisterRoutes(mux)
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestResourceServerDefaultsAudienceToResource(t *testing.T) {
+	const resource = "https://mcp.example.com/mcp"
+	s := NewServer("users",
+		WithResourceMetadata(auth.ResourceMetadata{
+			Resource:             resource,
+			AuthorizationServers: []string{"https://auth.example.com"},
+		}),
+		WithAuthenticator(auth.NewHMACAuthenticator(testSecret)),
+	)
+	if err := s.validateAuth(); err != nil {
+		t.Fatalf("validateAuth() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		status int
+	}{
+		{"resource audience", map[string]interface{}{"sub": "alice", "aud": resource}, http.StatusOK},
+		{"other resource", map[string]interface{}{"sub": "alice", "aud": "https://other.example.com/mcp"}, http.StatusUnauthorized},
+		{"no audience", map[string]interface{}{"sub": "alice"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		w := serveMCP(t, s, signToken(t, tt.claims))
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if tt.status == http.StatusUnauthorized {
+			challenge := w.Header().Get("WWW-Authenticate")
+			if !strings.Contains(challenge, `resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`) {
+				t.Errorf("%s: WWW-Authenticate = %q, want resource_metadata", tt.name, challenge)
+			}
+		}
+	}
+}
+
+func TestServerWithoutResourceKeepsAuthenticators(t *testing.T) {
+	s := NewServer("users", WithAuthenticator(auth.NewHMACAuthenticator(testSecret)))
+	w := serveMCP(t, s, signToken(t, map[string]interface{}{"sub": "alice", "aud": "anything"}))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 when no resource metadata is configured", w.Code)
+	}
+}
+
+func TestValidateAuthResourceMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			"no authorization server",
+			[]Option{WithResourceMetadata(auth.ResourceMetadata{}), WithAuthenticator(auth.NewHMACAuthenticator(testSecret))},
+			"authorization server",
+		},
+		{
+			"no authenticator",
+			[]Option{WithResourceMetadata(auth.ResourceMetadata{AuthorizationServers: []string{"https://auth.example.com"}})},
+			"requires an authenticator",
+		},
+	}
+	for _, tt := range tests {
+		err := NewServer("users", tt.opts...).validateAuth()
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: validateAuth() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// mcp-manifest 从运行中的MCP服务器读取工具列表，生成可编辑的工具元数据清单
+//
+// 用法:
+//
+//	mcp-manifest -url http://127.0.0.1:8080/mcp -o tools.yaml
+//	mcp-manifest -url https://mcp.example.com/mcp -token $ACCESS_TOKEN -o tools.yaml
+//	mcp-manifest -url http://127.0.0.1:8080/mcp -header "X-API-Key: $API_KEY" -o tools.yaml
+//
+// 清单以扫描得到的工具名为键，已被重命名的工具在 name 中记录当前名称，可直接通过 WithManifest 加载
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	nacosmcp "nacos-mcp-go"
+	"nacos-mcp-go/handler"
+)
+
+// headerFlags 可重复的 -header 参数
+type headerFlags http.Header
+
+// String 实现 flag.Value 接口
+func (h headerFlags) String() string {
+	var parts []string
+	for name, values := range h {
+		for _, value := range values {
+			parts = append(parts, name+": "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set 实现 flag.Value 接口，参数格式为 "Name: value"
+func (h headerFlags) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("header %q must be like \"Name: value\"", value)
+	}
+	http.Header(h).Add(name, strings.TrimSpace(val))
+	return nil
+}
+
+func main() {
+	headers := headerFlags{}
+	url := flag.String("url", "http://127.0.0.1:8080/mcp", "MCP endpoint of the running server")
+	output := flag.String("o", "-", "output file, - for stdout")
+	format := flag.String("format", "", "manifest format: yaml or json (default: from output extension, yaml for stdout)")
+	token := flag.String("token", "", "bearer token sent in the Authorization header")
+	flag.Var(headers, "header", `extra request header "Name: value", may be repeated`)
+	flag.Parse()
+
+	if *token != "" {
+		http.Header(headers).Set("Authorization", "Bearer "+*token)
+	}
+
+	tools, err := listTools(*url, http.Header(headers))
+	if err != nil {
+		log.Fatalf("List tools failed: %v", err)
+	}
+
+	manifestFormat := nacosmcp.ManifestFormat(*format)
+	if manifestFormat == "" {
+		manifestFormat = nacosmcp.ManifestYAML
+		if *output != "-" {
+			manifestFormat = nacosmcp.ManifestFormatOf(*output)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Create output file failed: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := nacosmcp.NewManifest(tools).Write(out, manifestFormat); err != nil {
+		log.Fatalf("Write manifest failed: %v", err)
+	}
+}
+
+// rpcClient 最小化的JSON-RPC客户端
+type rpcClient struct {
+	url     string
+	header  http.Header // 每个请求附带的请求头，如 Authorization
+	client  *http.Client
+	nextID  int
+	session string // 服务端在 initialize 响应中分配的会话ID
+}
+
+// call 发送JSON-RPC请求并解码结果
+func (c *rpcClient) call(method string, params interface{}, result interface{}) error {
+	c.nextID++
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	if c.session != "" {
+		req.Header.Set(handler.SessionHeader, c.session)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: unexpected status %d: %s", method, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if session := resp.Header.Get(handler.SessionHeader); session != "" {
+		c.session = session
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("%s: decode response failed: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s: %d %s", method, response.Error.Code, response.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// listedTool tools/list 中的工具，_meta 中带有重命名前扫描得到的工具名
+type listedTool struct {
+	nacosmcp.Tool
+	Meta map[string]interface{} `json:"_meta"`
+}
+
+// listTools 初始化会话并分页读取全部工具
+func listTools(url string, header http.Header) ([]nacosmcp.Tool, error) {
+	client := &rpcClient{url: url, header: header, client: &http.Client{Timeout: 30 * time.Second}}
+
+	err := client.call("initialize", map[string]interface{}{
+		"protocolVersion": handler.LatestProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "mcp-manifest", "version": "1.0.0"},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []nacosmcp.Tool
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var page struct {
+			Tools      []listedTool `json:"tools"`
+			NextCursor string       `json:"nextCursor"`
+		}
+		if err := client.call("tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		for _, listed := range page.Tools {
+			tool := listed.Tool
+			tool.ScannedName, _ = listed.Meta[handler.ScannedNameMetaKey].(string)
+			tools = append(tools, tool)
+		}
+
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	nacosmcp "nacos-mcp-go"
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/handler"
+)
+
+// GetTime 获取当前时间
+func GetTime() string { return "" }
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	manifest := &nacosmcp.Manifest{Tools: map[string]*nacosmcp.ToolManifest{
+		"get_time": {Name: "current_time"},
+	}}
+	server := nacosmcp.NewServer("clock", nacosmcp.WithManifest(manifest))
+	if err := server.RegisterTool(GetTime); err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+
+	h := handler.NewHTTPHandler(server, handler.WithAuthenticators(
+		auth.NewAPIKeyAuthenticator(map[string]*auth.Principal{"secret": {Subject: "ci"}}),
+	))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestListToolsKeyedByScannedName(t *testing.T) {
+	ts := newTestServer(t)
+	headers := headerFlags{}
+	if err := headers.Set("X-API-Key: secret"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	tools, err := listTools(ts.URL+"/mcp", http.Header(headers))
+	if err != nil {
+		t.Fatalf("listTools() error = %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "current_time" || tools[0].ScannedName != "get_time" {
+		t.Fatalf("tools = %+v, want current_time scanned as get_time", tools)
+	}
+
+	manifest := nacosmcp.NewManifest(tools)
+	entry, ok := manifest.Tools["get_time"]
+	if !ok || entry.Name != "current_time" {
+		t.Errorf("manifest tools = %+v, want get_time renamed to current_time", manifest.Tools)
+	}
+}
+
+func TestListToolsRequiresCredentials(t *testing.T) {
+	ts := newTestServer(t)
+	_, err := listTools(ts.URL+"/mcp", http.Header{})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("listTools() without credentials error = %v, want 401", err)
+	}
+
+	headers := headerFlags{}
+	if err := headers.Set("X-API-Key: wrong"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := listTools(ts.URL+"/mcp", http.Header(headers)); err == nil {
+		t.Fatal("listTools() with a wrong API key succeeded")
+	}
+}
+
+func TestHeaderFlags(t *testing.T) {
+	headers := headerFlags{}
+	for _, value := range []string{"X-Tenant: a", "X-Tenant:b", "Authorization: Bearer t:1"} {
+		if err := headers.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	h := http.Header(headers)
+	if got := h.Values("X-Tenant"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("X-Tenant = %v, want [a b]", got)
+	}
+	if got := h.Get("Authorization"); got != "Bearer t:1" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer t:1")
+	}
+	for _, value := range []string{"no-colon", ": value"} {
+		if err := headers.Set(value); err == nil {
+			t.Errorf("Set(%q) error = nil, want error", value)
+		}
+	}
+}
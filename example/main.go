@@ -84,14 +84,19 @@ func main() {
 		log.Fatalf("Failed to register MCP service: %v", err)
 	}
 
-	// 注册单个函数
-	server.RegisterTool(func(count int) []int {
+	// 注册单个函数，匿名函数需要显式指定工具名
+	if err := server.RegisterTool(func(count int) []int {
 		result := make([]int, count)
 		for i := 0; i < count; i++ {
 			result[i] = i + 1
 		}
 		return result
-	})
+	},
+		nacosmcp.WithToolName("generate_sequence"),
+		nacosmcp.WithToolDescription("生成从1开始的整数序列"),
+	); err != nil {
+		log.Fatalf("Failed to register tool: %v", err)
+	}
 
 	fmt.Printf("🚀 MCP Server '%s' initialized\n", server.GetName())
 	fmt.Printf("📋 Protocol: %s\n", server.GetProtocol())
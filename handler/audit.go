@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/audit"
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// maxAuditBodySize 审计认证失败的调用时最多读取的请求体字节数
+const maxAuditBodySize = 1 << 20
+
+// WithAuditSinks 添加审计输出，每次工具调用结束后写入一条审计记录，认证失败被拒绝的调用同样记录
+func WithAuditSinks(sinks ...audit.Sink) Option {
+	return func(h *HTTPHandler) {
+		h.auditSinks = append(h.auditSinks, sinks...)
+	}
+}
+
+// auditEvent 创建调用开始时的审计记录，参数在调用前复制并脱敏，不受中间件修改的影响
+func (h *HTTPHandler) auditEvent(ctx context.Context, tool *types.Tool, arguments map[string]interface{}, start time.Time) *audit.Event {
+	if len(h.auditSinks) == 0 {
+		return nil
+	}
+
+	event := &audit.Event{
+		Time:      start,
+		Session:   sessionFromContext(ctx),
+		Tool:      tool.Name,
+		Arguments: redactArguments(tool.InputSchema, arguments),
+	}
+	if principal, ok := auth.FromContext(ctx); ok {
+		event.Principal = principal.Subject
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		event.TraceID = sc.TraceID().String()
+	}
+	return event
+}
+
+// recordAudit 补充调用结果后写入所有审计输出，写入失败只记录日志
+func (h *HTTPHandler) recordAudit(ctx context.Context, event *audit.Event, err error) {
+	if event == nil {
+		return
+	}
+
+	event.Duration = time.Since(event.Time)
+	event.Status = "ok"
+	if err != nil {
+		event.Status = "error"
+		event.ErrorType = errorType(err)
+	}
+	h.writeAudit(ctx, event)
+}
+
+// auditUnauthenticated 为认证失败被拒绝的工具调用写入审计记录，其他请求和不存在的工具不记录
+func (h *HTTPHandler) auditUnauthenticated(r *http.Request) {
+	if len(h.auditSinks) == 0 {
+		return
+	}
+	name, arguments, ok := requestedCall(r)
+	if !ok {
+		return
+	}
+	tool, ok := h.findTool(name)
+	if !ok {
+		return
+	}
+
+	h.writeAudit(r.Context(), &audit.Event{
+		Time:      time.Now(),
+		Tool:      tool.Name,
+		Arguments: redactArguments(tool.InputSchema, arguments),
+		Status:    "error",
+		ErrorType: "unauthenticated",
+	})
+}
+
+// requestedCall 读取请求中调用的工具名和参数，支持 /mcp 的 tools/call 和 /mcp/tools/{name}/invoke
+// 请求体最多读取 maxAuditBodySize 字节，请求未被处理，读取后无需恢复
+func requestedCall(r *http.Request) (string, map[string]interface{}, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodySize))
+	if err != nil {
+		return "", nil, false
+	}
+
+	var call struct {
+		Method string `json:"method"`
+		Params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"params"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	// 请求体无效时 /mcp/tools/{name}/invoke 仍按路径记录，参数为空
+	_ = unmarshalUseNumber(body, &call)
+
+	if path, ok := strings.CutPrefix(r.URL.Path, "/mcp/tools/"); ok {
+		name, ok := strings.CutSuffix(path, "/invoke")
+		return name, call.Arguments, ok && name != "" && !strings.Contains(name, "/")
+	}
+	if r.URL.Path == "/mcp" && call.Method == "tools/call" {
+		return call.Params.Name, call.Params.Arguments, true
+	}
+	return "", nil, false
+}
+
+// writeAudit 将审计记录写入所有审计输出，写入失败只记录日志
+func (h *HTTPHandler) writeAudit(ctx context.Context, event *audit.Event) {
+	for _, sink := range h.auditSinks {
+		if err := sink.Record(ctx, event); err != nil {
+			h.logger.Printf("Error recording audit event of tool %s: %v", event.Tool, err)
+		}
+	}
+}
+
+// redactArguments 返回参数的副本，schema中标记为 writeOnly 的敏感字段替换为 audit.Redacted
+func redactArguments(schema map[string]interface{}, arguments map[string]interface{}) map[string]interface{} {
+	redacted, _ := redactValue(schema, schema, arguments).(map[string]interface{})
+	if redacted == nil {
+		redacted = map[string]interface{}{}
+	}
+	return redacted
+}
+
+// redactValue 按schema递归复制值并替换敏感字段
+// 引用 $defs 的字段在解析引用前检查 writeOnly，字段上的标记不会因解析为被引用的定义而丢失
+func redactValue(root, schema map[string]interface{}, value interface{}) interface{} {
+	if isWriteOnly(schema) && value != nil {
+		return audit.Redacted
+	}
+	schema = resolveRef(root, schema)
+	if isWriteOnly(schema) && value != nil {
+		return audit.Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if variant := selectVariant(root, schema, v); variant != nil {
+			schema = variant
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		result := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			propSchema, ok := properties[name].(map[string]interface{})
+			if !ok {
+				propSchema = additional
+			}
+			result[name] = redactValue(root, propSchema, item)
+		}
+		return result
+
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactValue(root, items, item)
+		}
+		return result
+	}
+	return value
+}
+
+// isWriteOnly 判断schema是否标记为 writeOnly
+func isWriteOnly(schema map[string]interface{}) bool {
+	writeOnly, _ := schema["writeOnly"].(bool)
+	return writeOnly
+}
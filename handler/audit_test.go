@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"nacos-mcp-go/audit"
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// auditRecorder 收集审计记录的测试输出
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []*audit.Event
+}
+
+// Record 实现 audit.Sink 接口
+func (r *auditRecorder) Record(ctx context.Context, event *audit.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// loginTool 参数 password 标记为敏感的测试工具
+func loginTool() types.Tool {
+	tool := echoTool("login")
+	tool.InputSchema = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"user":     map[string]interface{}{"type": "string"},
+			"password": map[string]interface{}{"type": "string", "writeOnly": true},
+		},
+	}
+	return tool
+}
+
+func TestRedactArguments(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"user":   map[string]interface{}{"type": "string"},
+			"token":  map[string]interface{}{"type": "string", "writeOnly": true},
+			"secret": map[string]interface{}{"$ref": "#/$defs/Node", "writeOnly": true},
+			"root":   map[string]interface{}{"$ref": "#/$defs/Node"},
+			"keys": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string", "writeOnly": true},
+			},
+		},
+		"$defs": map[string]interface{}{
+			"Node": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":  map[string]interface{}{"type": "string"},
+					"pin":   map[string]interface{}{"type": "string", "writeOnly": true},
+					"child": map[string]interface{}{"$ref": "#/$defs/Node"},
+				},
+			},
+		},
+	}
+	arguments := map[string]interface{}{
+		"user":   "alice",
+		"token":  "t-123",
+		"secret": map[string]interface{}{"name": "vault", "pin": "0000"},
+		"root": map[string]interface{}{
+			"name":  "a",
+			"pin":   "1111",
+			"child": map[string]interface{}{"name": "b", "pin": "2222"},
+		},
+		"keys": []interface{}{"k1", "k2"},
+	}
+
+	got := redactArguments(schema, arguments)
+
+	if got["user"] != "alice" {
+		t.Errorf("user = %v, want alice", got["user"])
+	}
+	for _, name := range []string{"token", "secret"} {
+		if got[name] != audit.Redacted {
+			t.Errorf("%s = %v, want %s", name, got[name], audit.Redacted)
+		}
+	}
+	root := got["root"].(map[string]interface{})
+	child := root["child"].(map[string]interface{})
+	if root["name"] != "a" || root["pin"] != audit.Redacted || child["name"] != "b" || child["pin"] != audit.Redacted {
+		t.Errorf("root = %v, want pins redacted through $ref", root)
+	}
+	if keys := got["keys"].([]interface{}); keys[0] != audit.Redacted || keys[1] != audit.Redacted {
+		t.Errorf("keys = %v, want each item redacted", keys)
+	}
+	if secret := arguments["secret"].(map[string]interface{}); secret["pin"] != "0000" {
+		t.Error("redactArguments modified the original arguments")
+	}
+}
+
+func TestAuditRecordsToolCalls(t *testing.T) {
+	recorder := &auditRecorder{}
+	mux := newTestMux(t, []types.Tool{loginTool()}, WithAuditSinks(recorder))
+
+	w, _ := postRPC(t, mux, nil, "initialize", nil)
+	session := w.Header().Get(SessionHeader)
+	postRPC(t, mux, sessionHeader(session), "tools/call", callParams("login", map[string]interface{}{"user": "alice", "password": "s3cret"}))
+	postRPC(t, mux, nil, "tools/call", callParams("missing", nil))
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("recorded %d events, want 1 for the known tool", len(recorder.events))
+	}
+	event := recorder.events[0]
+	if event.Tool != "login" || event.Status != "ok" || event.Session != session {
+		t.Errorf("event = %+v, want an ok login call in session %s", event, session)
+	}
+	if event.Arguments["password"] != audit.Redacted || event.Arguments["user"] != "alice" {
+		t.Errorf("arguments = %v, want password redacted", event.Arguments)
+	}
+}
+
+func TestAuditRecordsUnauthenticatedCalls(t *testing.T) {
+	recorder := &auditRecorder{}
+	mux := newTestMux(t, []types.Tool{loginTool()},
+		WithAuditSinks(recorder),
+		WithAuthenticators(auth.NewAPIKeyAuthenticator(map[string]*auth.Principal{"k-alice": {Subject: "alice"}})),
+	)
+	arguments := map[string]interface{}{"user": "alice", "password": "s3cret"}
+
+	for _, header := range []http.Header{nil, apiKeyHeader("k-unknown")} {
+		if w, _ := postRPC(t, mux, header, "tools/call", callParams("login", arguments)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401", w.Code)
+		}
+	}
+	r := httptest.NewRequest(http.MethodPost, "/mcp/tools/login/invoke", strings.NewReader(`{"arguments":{"user":"alice","password":"s3cret"}}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("invoke status = %d, want 401", w.Code)
+	}
+
+	// 工具调用以外的请求和不存在的工具不记录
+	postRPC(t, mux, nil, "tools/list", nil)
+	postRPC(t, mux, nil, "tools/call", callParams("missing", nil))
+
+	if len(recorder.events) != 3 {
+		t.Fatalf("recorded %d events, want 3 rejected calls", len(recorder.events))
+	}
+	for i, event := range recorder.events {
+		if event.Tool != "login" || event.Status != "error" || event.ErrorType != "unauthenticated" || event.Principal != "" {
+			t.Errorf("event %d = %+v, want an unauthenticated login call", i, event)
+		}
+		if event.Arguments["password"] != audit.Redacted || event.Arguments["user"] != "alice" {
+			t.Errorf("event %d arguments = %v, want password redacted", i, event.Arguments)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// CodeForbidden 调用方无权调用工具时的JSON-RPC错误码
+const CodeForbidden = -32003
+
+// authenticatorKey 上下文中认证调用方的认证器序号的键
+type authenticatorKey struct{}
+
+// WithAuthenticators 设置认证器，设置后所有 MCP 接口都要求认证，按顺序尝试直到某个认证器识别出凭证
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(h *HTTPHandler) {
+		h.authenticators = append(h.authenticators, authenticators...)
+	}
+}
+
+// authenticate 认证请求并将调用方放入请求上下文，未配置认证器时直接放行
+func (h *HTTPHandler) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(h.authenticators) == 0 {
+			next(w, r)
+			return
+		}
+
+		principal, authenticator, err := h.authenticateRequest(r)
+		if errors.Is(err, auth.ErrUnavailable) {
+			h.writeUnavailable(w, err)
+			return
+		}
+		if err != nil {
+			h.auditUnauthenticated(r)
+			h.writeUnauthorized(w, err)
+			return
+		}
+		ctx := context.WithValue(auth.NewContext(r.Context(), principal), authenticatorKey{}, authenticator)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// authenticateRequest 依次尝试各认证器，返回调用方和认证成功的认证器序号，所有认证器都未找到凭证时返回 ErrUnauthenticated
+func (h *HTTPHandler) authenticateRequest(r *http.Request) (*auth.Principal, int, error) {
+	for i, authenticator := range h.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if principal == nil {
+			return nil, 0, fmt.Errorf("%w: authenticator returned no principal", auth.ErrInvalidCredentials)
+		}
+		return principal, i, nil
+	}
+	return nil, 0, auth.ErrUnauthenticated
+}
+
+// authenticatorFromContext 读取上下文中认证调用方的认证器序号
+func authenticatorFromContext(ctx context.Context) int {
+	authenticator, _ := ctx.Value(authenticatorKey{}).(int)
+	return authenticator
+}
+
+// principalKey 返回区分已认证调用方的键，由认证器序号、令牌签发方和调用方标识组成
+// 不同认证器或签发方中的同名调用方得到不同的键；匿名调用方和没有标识的调用方无法区分，返回 false
+func principalKey(ctx context.Context) (string, bool) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.Subject == "" {
+		return "", false
+	}
+	issuer, _ := principal.Claims["iss"].(string)
+	return fmt.Sprintf("%d\x00%s\x00%s", authenticatorFromContext(ctx), issuer, principal.Subject), true
+}
+
+// WithResourceMetadata 设置OAuth受保护资源元数据，通过 well-known 接口公开，并在401质询中指向该接口
+func WithResourceMetadata(metadata *auth.ResourceMetadata) Option {
+	return func(h *HTTPHandler) {
+		h.resourceMetadata = metadata
+	}
+}
+
+// protectedResource 处理 /.well-known/oauth-protected-resource - 受保护资源元数据，无需认证
+func (h *HTTPHandler) protectedResource(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.resourceMetadata); err != nil {
+		h.logger.Printf("Error encoding resource metadata: %v", err)
+	}
+}
+
+// challenge 构建 WWW-Authenticate 质询，配置了资源元数据时附带其地址
+func (h *HTTPHandler) challenge(errorCode string, scopes []string) string {
+	var params []string
+	if errorCode != "" {
+		params = append(params, fmt.Sprintf("error=%q", errorCode))
+	}
+	if len(scopes) > 0 {
+		params = append(params, fmt.Sprintf("scope=%q", strings.Join(scopes, " ")))
+	}
+	if h.resourceMetadata != nil {
+		params = append(params, fmt.Sprintf("resource_metadata=%q", h.resourceMetadata.MetadataURL()))
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// writeUnauthorized 返回401响应
+func (h *HTTPHandler) writeUnauthorized(w http.ResponseWriter, err error) {
+	errorCode := ""
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		h.logger.Printf("Authentication failed: %v", err)
+		errorCode = "invalid_token"
+	}
+	w.Header().Set("WWW-Authenticate", h.challenge(errorCode, nil))
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// writeUnavailable 认证依赖的服务不可用时写入503，令牌的有效性未知，不作为无效凭证质询
+func (h *HTTPHandler) writeUnavailable(w http.ResponseWriter, err error) {
+	h.logger.Printf("Authentication unavailable: %v", err)
+	http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+}
+
+// writeForbidden 写入权限不足时的403状态和质询，响应体由调用方写入；缺少 scope 时客户端可据此重新申请令牌
+func (h *HTTPHandler) writeForbidden(w http.ResponseWriter, scopes []string) {
+	if len(scopes) > 0 {
+		w.Header().Set("WWW-Authenticate", h.challenge("insufficient_scope", scopes))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+}
+
+// ScopeError 调用方缺少工具要求的 scope
+type ScopeError struct {
+	Tool   string
+	Scopes []string // 工具要求的全部 scope
+}
+
+// Error 实现error接口
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("%v: tool %s requires scope %q", auth.ErrForbidden, e.Tool, strings.Join(e.Scopes, " "))
+}
+
+// Unwrap 使 errors.Is(err, auth.ErrForbidden) 成立
+func (e *ScopeError) Unwrap() error {
+	return auth.ErrForbidden
+}
+
+// authorize 按工具的访问规则检查上下文中的调用方
+func authorize(ctx context.Context, tool *types.Tool) error {
+	rule := tool.Auth
+	if rule == nil || (len(rule.Principals) == 0 && len(rule.Scopes) == 0) {
+		return nil
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: tool %s requires authentication", auth.ErrUnauthenticated, tool.Name)
+	}
+	if len(rule.Principals) > 0 && !slices.Contains(rule.Principals, principal.Subject) {
+		return fmt.Errorf("%w: %q is not allowed to call tool %s", auth.ErrForbidden, principal.Subject, tool.Name)
+	}
+	for _, scope := range rule.Scopes {
+		if !principal.HasScope(scope) {
+			return &ScopeError{Tool: tool.Name, Scopes: rule.Scopes}
+		}
+	}
+	return nil
+}
+
+// forbiddenError 将权限错误转换为JSON-RPC错误，缺少 scope 时在 data 中给出所需的 scope
+func forbiddenError(err error) *RPCError {
+	rpcErr := &RPCError{Code: CodeForbidden, Message: err.Error()}
+	var scopeErr *ScopeError
+	if errors.As(err, &scopeErr) {
+		rpcErr.Data = map[string]interface{}{"requiredScopes": scopeErr.Scopes}
+	}
+	return rpcErr
+}
+
+// requiredScopes 读取权限错误中所需的 scope
+func requiredScopes(rpcErr *RPCError) []string {
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	scopes, _ := data["requiredScopes"].([]string)
+	return scopes
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// apiKeyHeader 返回携带API Key的请求头
+func apiKeyHeader(key string) http.Header {
+	return http.Header{auth.APIKeyHeader: []string{key}}
+}
+
+func newAuthMux(t *testing.T, tools ...types.Tool) *http.ServeMux {
+	t.Helper()
+	return newTestMux(t, tools, WithAuthenticators(auth.NewAPIKeyAuthenticator(map[string]*auth.Principal{
+		"k-alice": {Subject: "alice", Scopes: []string{"users:read"}},
+		"k-bob":   {Subject: "bob", Scopes: []string{"users:read", "users:write"}},
+	})))
+}
+
+func TestAuthenticateRejectsMissingCredentials(t *testing.T) {
+	mux := newAuthMux(t, echoTool("echo"))
+
+	for name, header := range map[string]http.Header{
+		"no credentials": nil,
+		"unknown key":    apiKeyHeader("k-unknown"),
+	} {
+		w, _ := postRPC(t, mux, header, "tools/list", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", name, w.Code)
+		}
+		if challenge := w.Header().Get("WWW-Authenticate"); !strings.HasPrefix(challenge, "Bearer") {
+			t.Errorf("%s: WWW-Authenticate = %q, want a Bearer challenge", name, challenge)
+		}
+	}
+
+	w, _ := postRPC(t, mux, apiKeyHeader("k-unknown"), "tools/list", nil)
+	if challenge := w.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, `error="invalid_token"`) {
+		t.Errorf("unknown key: WWW-Authenticate = %q, want invalid_token", challenge)
+	}
+
+	w, resp := postRPC(t, mux, apiKeyHeader("k-alice"), "tools/list", nil)
+	if w.Code != http.StatusOK || resp.Error != nil {
+		t.Errorf("valid key: status = %d, error = %+v", w.Code, resp.Error)
+	}
+}
+
+func TestAuthorizeAllowedPrincipals(t *testing.T) {
+	tool := echoTool("delete_user")
+	tool.Auth = &types.AuthRule{Principals: []string{"bob"}}
+	mux := newAuthMux(t, tool)
+
+	w, resp := postRPC(t, mux, apiKeyHeader("k-alice"), "tools/call", callParams("delete_user", nil))
+	if w.Code != http.StatusForbidden || resp.Error == nil || resp.Error.Code != CodeForbidden {
+		t.Fatalf("alice: status = %d, error = %+v, want 403 with %d", w.Code, resp.Error, CodeForbidden)
+	}
+	if challenge := w.Header().Get("WWW-Authenticate"); challenge != "" {
+		t.Errorf("alice: WWW-Authenticate = %q, want none when no scope is missing", challenge)
+	}
+
+	w, resp = postRPC(t, mux, apiKeyHeader("k-bob"), "tools/call", callParams("delete_user", map[string]interface{}{"message": "ok"}))
+	if w.Code != http.StatusOK || resp.Error != nil {
+		t.Fatalf("bob: status = %d, error = %+v", w.Code, resp.Error)
+	}
+}
+
+func TestAuthorizeRequiredScopes(t *testing.T) {
+	tool := echoTool("update_user")
+	tool.Auth = &types.AuthRule{Scopes: []string{"users:write"}}
+	mux := newAuthMux(t, tool)
+
+	w, resp := postRPC(t, mux, apiKeyHeader("k-alice"), "tools/call", callParams("update_user", nil))
+	if w.Code != http.StatusForbidden || resp.Error == nil {
+		t.Fatalf("alice: status = %d, error = %+v, want 403", w.Code, resp.Error)
+	}
+	challenge := w.Header().Get("WWW-Authenticate")
+	if !strings.Contains(challenge, `error="insufficient_scope"`) || !strings.Contains(challenge, `scope="users:write"`) {
+		t.Errorf("alice: WWW-Authenticate = %q, want insufficient_scope for users:write", challenge)
+	}
+	if scopes, _ := resp.Error.Data["requiredScopes"].([]interface{}); len(scopes) != 1 || scopes[0] != "users:write" {
+		t.Errorf("alice: requiredScopes = %v, want [users:write]", resp.Error.Data["requiredScopes"])
+	}
+
+	w, resp = postRPC(t, mux, apiKeyHeader("k-bob"), "tools/call", callParams("update_user", nil))
+	if w.Code != http.StatusOK || resp.Error != nil {
+		t.Fatalf("bob: status = %d, error = %+v", w.Code, resp.Error)
+	}
+}
+
+func TestInvokeToolRequiresAuthentication(t *testing.T) {
+	mux := newAuthMux(t, echoTool("echo"))
+	for _, path := range []string{"/mcp/tools", "/mcp/info", "/mcp/tools/echo/invoke"} {
+		r, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		if path != "/mcp/tools/echo/invoke" {
+			r.Method = http.MethodGet
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", path, w.Code)
+		}
+	}
+}
+
+func TestUnauthorizedChallengeCarriesResourceMetadata(t *testing.T) {
+	metadata := &auth.ResourceMetadata{
+		Resource:             "https://mcp.example.com/mcp",
+		AuthorizationServers: []string{"https://auth.example.com"},
+	}
+	mux := newTestMux(t, []types.Tool{echoTool("echo")},
+		WithResourceMetadata(metadata),
+		WithAuthenticators(auth.NewHMACAuthenticator([]byte("0123456789abcdef0123456789abcdef"))),
+	)
+
+	want := `resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp"`
+	for name, header := range map[string]http.Header{
+		"no token":      nil,
+		"invalid token": {"Authorization": []string{"Bearer not-a-jwt"}},
+	} {
+		w, _ := postRPC(t, mux, header, "tools/list", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: status = %d, want 401", name, w.Code)
+		}
+		challenge := w.Header().Get("WWW-Authenticate")
+		if !strings.HasPrefix(challenge, "Bearer ") || !strings.Contains(challenge, want) {
+			t.Errorf("%s: WWW-Authenticate = %q, want Bearer challenge with %s", name, challenge, want)
+		}
+	}
+
+	// 元数据接口无需认证
+	for _, path := range []string{auth.ResourceMetadataPath, auth.ResourceMetadataPath + "/mcp"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", path, w.Code)
+		}
+		var got auth.ResourceMetadata
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: decode metadata: %v", path, err)
+		}
+		if got.Resource != metadata.Resource || len(got.AuthorizationServers) != 1 {
+			t.Errorf("%s: metadata = %+v, want %+v", path, got, metadata)
+		}
+	}
+}
+
+func TestAuthenticationUnavailable(t *testing.T) {
+	unavailable := auth.AuthenticatorFunc(func(r *http.Request) (*auth.Principal, error) {
+		return nil, fmt.Errorf("%w: token introspection failed with status 502", auth.ErrUnavailable)
+	})
+	mux := newTestMux(t, []types.Tool{echoTool("echo")}, WithAuthenticators(unavailable))
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	r.Header.Set("Authorization", "Bearer opaque")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	if challenge := w.Header().Get("WWW-Authenticate"); challenge != "" {
+		t.Errorf("WWW-Authenticate = %q, want no challenge for an unavailable authenticator", challenge)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// DefaultCacheSize 结果缓存默认的最大条目数
+const DefaultCacheSize = 1024
+
+// WithCacheSize 设置结果缓存的最大条目数，超出时淘汰最久未使用的条目
+func WithCacheSize(size int) Option {
+	return func(h *HTTPHandler) {
+		h.cache = newResultCache(size)
+	}
+}
+
+// cacheKey 缓存键，由工具名、调用方和规范化的参数计算
+type cacheKey [sha256.Size]byte
+
+// cacheEntry 缓存的调用结果
+type cacheEntry struct {
+	key     cacheKey
+	result  interface{}
+	expires time.Time
+}
+
+// resultCache 带过期时间的LRU结果缓存
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[cacheKey]*list.Element
+	order   *list.List // 最近使用的条目在前
+}
+
+// newResultCache 创建最多保存 size 个条目的结果缓存
+func newResultCache(size int) *resultCache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &resultCache{
+		size:    size,
+		entries: make(map[cacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get 读取未过期的缓存结果
+func (c *resultCache) get(key cacheKey) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// put 保存调用结果，超出容量时淘汰最久未使用的条目
+func (c *resultCache) put(key cacheKey, result interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, result: result, expires: time.Now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cachePartition 返回调用方的缓存分区，由认证调用方的认证器、令牌签发方和调用方标识组成，匿名调用共用空分区
+// 不同认证器或签发方中的同名调用方读不到彼此的结果；已认证但没有标识的调用方无法区分，不缓存
+func cachePartition(ctx context.Context) (string, bool) {
+	if _, ok := auth.FromContext(ctx); !ok {
+		return "", true
+	}
+	return principalKey(ctx)
+}
+
+// resultCacheKey 计算调用的缓存键
+// 参数按JSON编码规范化（对象的键有序），结果按调用方分区，不同调用方不会读到彼此的结果
+func resultCacheKey(ctx context.Context, tool *types.Tool, arguments map[string]interface{}) (cacheKey, bool) {
+	partition, ok := cachePartition(ctx)
+	if !ok {
+		return cacheKey{}, false
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return cacheKey{}, false
+	}
+
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(tool.Name), []byte(partition), data} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	var key cacheKey
+	hash.Sum(key[:0])
+	return key, true
+}
+
+// cachedInvoke 对开启缓存的工具先查找缓存，未命中时调用工具函数并缓存成功的结果
+// 位于中间件链的最内层，命中时中间件、超时和并发限制仍然生效，缓存键使用中间件修改后的参数
+// 流式工具的每次调用都需要推送进度通知，不缓存
+func (h *HTTPHandler) cachedInvoke(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+	tool := req.Tool
+	if tool.CacheTTL <= 0 {
+		return h.invoke(ctx, req)
+	}
+	if _, ok := streamSignature(tool); ok {
+		return h.invoke(ctx, req)
+	}
+	key, ok := resultCacheKey(ctx, tool, req.Arguments)
+	if !ok {
+		return h.invoke(ctx, req)
+	}
+
+	if result, ok := h.cache.get(key); ok {
+		h.metrics.ObserveCache(tool.Name, true)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("mcp.tool.cache_hit", true))
+		return result, nil
+	}
+	h.metrics.ObserveCache(tool.Name, false)
+
+	result, err := h.invoke(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	h.cache.put(key, result, tool.CacheTTL)
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/types"
+)
+
+// countingTool 开启缓存、记录调用次数的测试工具
+func countingTool(name string, calls *int) types.Tool {
+	tool := echoTool(name)
+	tool.CacheTTL = time.Minute
+	tool.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		*calls++
+		return arguments["message"], nil
+	}
+	return tool
+}
+
+// callCached 调用工具并要求调用成功
+func callCached(t *testing.T, handler http.Handler, header http.Header, name string) {
+	t.Helper()
+	w, resp := postRPC(t, handler, header, "tools/call", callParams(name, map[string]interface{}{"message": "hi"}))
+	if w.Code != http.StatusOK || resp.Error != nil {
+		t.Fatalf("status = %d, error = %+v", w.Code, resp.Error)
+	}
+}
+
+// headerAuthenticator 以请求头 X-Subject 和 X-Issuer 作为调用方、授予 users:read 的测试认证器
+func headerAuthenticator() auth.Authenticator {
+	return auth.AuthenticatorFunc(func(r *http.Request) (*auth.Principal, error) {
+		if _, ok := r.Header["X-Subject"]; !ok {
+			return nil, auth.ErrNoCredentials
+		}
+		return &auth.Principal{
+			Subject: r.Header.Get("X-Subject"),
+			Scopes:  []string{"users:read"},
+			Claims:  map[string]interface{}{"iss": r.Header.Get("X-Issuer")},
+		}, nil
+	})
+}
+
+func TestCacheHitsRunMiddleware(t *testing.T) {
+	var calls, middlewareCalls int
+	counter := func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			middlewareCalls++
+			return next(ctx, req)
+		}
+	}
+	mux := newTestMux(t, []types.Tool{countingTool("lookup", &calls)}, WithMiddleware(counter))
+
+	for i := 0; i < 3; i++ {
+		callCached(t, mux, nil, "lookup")
+	}
+	if calls != 1 {
+		t.Errorf("tool calls = %d, want 1", calls)
+	}
+	if middlewareCalls != 3 {
+		t.Errorf("middleware calls = %d, want 3 including cache hits", middlewareCalls)
+	}
+}
+
+func TestCacheKeyUsesMiddlewareArguments(t *testing.T) {
+	var calls int
+	rewrite := func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			req.Arguments = map[string]interface{}{"message": req.Session}
+			return next(ctx, req)
+		}
+	}
+	mux := newTestMux(t, []types.Tool{countingTool("lookup", &calls)}, WithMiddleware(rewrite))
+
+	for i := 0; i < 2; i++ {
+		w, _ := postRPC(t, mux, nil, "initialize", nil)
+		callCached(t, mux, sessionHeader(w.Header().Get(SessionHeader)), "lookup")
+	}
+	if calls != 2 {
+		t.Errorf("tool calls = %d, want 2 for arguments rewritten per session", calls)
+	}
+}
+
+func TestCachePartitionsByAuthenticatorAndIssuer(t *testing.T) {
+	var calls int
+	mux := newTestMux(t, []types.Tool{countingTool("lookup", &calls)}, WithAuthenticators(
+		auth.NewAPIKeyAuthenticator(map[string]*auth.Principal{"k-alice": {Subject: "alice"}}),
+		headerAuthenticator(),
+	))
+
+	callers := []http.Header{
+		apiKeyHeader("k-alice"),
+		{"X-Subject": {"alice"}, "X-Issuer": {"https://a.example.com"}},
+		{"X-Subject": {"alice"}, "X-Issuer": {"https://b.example.com"}},
+	}
+	for _, header := range callers {
+		callCached(t, mux, header, "lookup")
+	}
+	if calls != len(callers) {
+		t.Fatalf("tool calls = %d, want %d: the same subject from another authenticator or issuer hit the cache", calls, len(callers))
+	}
+
+	for _, header := range callers {
+		callCached(t, mux, header, "lookup")
+	}
+	if calls != len(callers) {
+		t.Errorf("tool calls = %d after repeated calls, want %d", calls, len(callers))
+	}
+}
+
+func TestCacheSkipsPrincipalsWithoutSubject(t *testing.T) {
+	var calls int
+	tool := countingTool("lookup", &calls)
+	tool.Auth = &types.AuthRule{Scopes: []string{"users:read"}}
+	mux := newTestMux(t, []types.Tool{tool}, WithAuthenticators(headerAuthenticator()))
+
+	for i := 0; i < 2; i++ {
+		callCached(t, mux, http.Header{"X-Subject": {""}}, "lookup")
+	}
+	if calls != 2 {
+		t.Errorf("tool calls = %d, want 2 when the caller has no subject", calls)
+	}
+}
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResultCache(2)
+	keys := []cacheKey{{1}, {2}, {3}}
+
+	cache.put(keys[0], "a", time.Minute)
+	cache.put(keys[1], "b", time.Minute)
+	cache.get(keys[0])
+	cache.put(keys[2], "c", time.Minute)
+
+	if _, ok := cache.get(keys[1]); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, key := range []cacheKey{keys[0], keys[2]} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("entry %v was evicted", key[0])
+		}
+	}
+
+	cache.put(keys[0], "expired", -time.Second)
+	if _, ok := cache.get(keys[0]); ok {
+		t.Error("expired entry was returned")
+	}
+}
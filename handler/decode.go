@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"nacos-mcp-go/scanner"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unmarshalUseNumber 解析JSON，数值保留为 json.Number 以避免精度丢失
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// decodeError 构造带参数路径的解码错误，与校验错误一样作为无效参数返回
+func decodeError(path, format string, args ...interface{}) error {
+	return &ValidationError{Issues: []ValidationIssue{{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	}}}
+}
+
+// decoder 将JSON参数值解码为Go值，注册表中的解码器和多态定义优先
+type decoder struct {
+	registry *scanner.TypeRegistry
+}
+
+// DecodeArguments 将参数对象解码为目标类型，规则与反射调用工具函数时的参数解码一致
+// 解码失败时返回带参数路径的 *ValidationError
+func DecodeArguments(arguments map[string]interface{}, targetType reflect.Type, registry *scanner.TypeRegistry) (reflect.Value, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	return decoder{registry: registry}.decodeValue(arguments, targetType, "")
+}
+
+// decodeValue 将JSON参数值无损地解码为目标类型
+// 整数不允许小数和溢出，json.Unmarshaler、encoding.TextUnmarshaler 类型使用其自身的解码逻辑
+func (d decoder) decodeValue(value interface{}, targetType reflect.Type, path string) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(targetType), nil
+	}
+
+	// 注册表中的解码器优先
+	if decode, ok := d.registry.Decoder(targetType); ok {
+		return d.decodeRegistered(decode, value, targetType, path)
+	}
+
+	// 其次使用类型自定义的解码逻辑
+	if targetType.Kind() != reflect.Ptr && targetType.Kind() != reflect.Interface {
+		ptrType := reflect.PointerTo(targetType)
+		if ptrType.Implements(jsonUnmarshalerType) {
+			return d.decodeJSONUnmarshaler(value, targetType, path)
+		}
+		if ptrType.Implements(textUnmarshalerType) {
+			if str, ok := value.(string); ok {
+				ptr := reflect.New(targetType)
+				if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+					return reflect.Value{}, decodeError(path, "invalid %s: %v", targetType, err)
+				}
+				return ptr.Elem(), nil
+			}
+		}
+	}
+
+	// time.Duration 接受Go时长字符串或纳秒数
+	if targetType == durationType {
+		if str, ok := value.(string); ok {
+			d, err := time.ParseDuration(str)
+			if err != nil {
+				return reflect.Value{}, decodeError(path, "invalid duration %q", str)
+			}
+			return reflect.ValueOf(d), nil
+		}
+	}
+
+	switch targetType.Kind() {
+	case reflect.String:
+		str, ok := value.(string)
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be string, got %s", jsonTypeName(value))
+		}
+		if targetType == jsonNumberType {
+			if _, err := strconv.ParseFloat(str, 64); err != nil {
+				return reflect.Value{}, decodeError(path, "invalid number %q", str)
+			}
+		}
+		return reflect.ValueOf(str).Convert(targetType), nil
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be boolean, got %s", jsonTypeName(value))
+		}
+		return reflect.ValueOf(b).Convert(targetType), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return decodeInteger(value, targetType, path)
+	case reflect.Float32, reflect.Float64:
+		return decodeFloat(value, targetType, path)
+	case reflect.Slice:
+		// []byte 以base64字符串传递
+		if str, ok := value.(string); ok && targetType.Elem().Kind() == reflect.Uint8 {
+			data, err := base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return reflect.Value{}, decodeError(path, "invalid base64 string")
+			}
+			return reflect.ValueOf(data).Convert(targetType), nil
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be array, got %s", jsonTypeName(value))
+		}
+		result := reflect.MakeSlice(targetType, len(items), len(items))
+		for i, item := range items {
+			elem, err := d.decodeValue(item, targetType.Elem(), fmt.Sprintf("%s/%d", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(elem)
+		}
+		return result, nil
+	case reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be array, got %s", jsonTypeName(value))
+		}
+		if len(items) != targetType.Len() {
+			return reflect.Value{}, decodeError(path, "must contain exactly %d items", targetType.Len())
+		}
+		result := reflect.New(targetType).Elem()
+		for i, item := range items {
+			elem, err := d.decodeValue(item, targetType.Elem(), fmt.Sprintf("%s/%d", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(i).Set(elem)
+		}
+		return result, nil
+	case reflect.Map:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be object, got %s", jsonTypeName(value))
+		}
+		return d.decodeMap(obj, targetType, path)
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, decodeError(path, "must be object, got %s", jsonTypeName(value))
+		}
+		return d.decodeStruct(obj, targetType, path)
+	case reflect.Ptr:
+		elem, err := d.decodeValue(value, targetType.Elem(), path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(targetType.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Interface:
+		// 注册了多态定义的接口按判别属性解码为具体类型
+		if union, ok := d.registry.Union(targetType); ok {
+			return d.decodeUnion(value, targetType, union, path)
+		}
+		// 空接口保留原始JSON值，数值为 json.Number
+		if targetType.NumMethod() == 0 {
+			result := reflect.New(targetType).Elem()
+			result.Set(reflect.ValueOf(value))
+			return result, nil
+		}
+		return reflect.Value{}, decodeError(path, "cannot decode %s into interface %s", jsonTypeName(value), targetType)
+	}
+
+	return reflect.Value{}, decodeError(path, "unsupported parameter type %s", targetType)
+}
+
+// decodeUnion 按判别属性的值将JSON对象解码为接口的具体实现
+func (d decoder) decodeUnion(value interface{}, targetType reflect.Type, union *scanner.Union, path string) (reflect.Value, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, decodeError(path, "cannot decode %s into %s", jsonTypeName(value), targetType)
+	}
+
+	discriminatorPath := path + "/" + escapePointer(union.Discriminator)
+	kind, ok := obj[union.Discriminator].(string)
+	if !ok {
+		return reflect.Value{}, decodeError(discriminatorPath, "is required")
+	}
+	variant, ok := union.Variants[kind]
+	if !ok {
+		values := make([]interface{}, 0, len(union.Variants))
+		for _, v := range union.Values() {
+			values = append(values, v)
+		}
+		return reflect.Value{}, decodeError(discriminatorPath, "must be one of %s", formatEnum(values))
+	}
+
+	decoded, err := d.decodeValue(obj, variant, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	result := reflect.New(targetType).Elem()
+	result.Set(decoded)
+	return result, nil
+}
+
+// decodeRegistered 使用注册表中的解码器解码，解码结果必须可赋值给目标类型
+func (d decoder) decodeRegistered(decode scanner.DecodeFunc, value interface{}, targetType reflect.Type, path string) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, decodeError(path, "cannot encode value: %v", err)
+	}
+
+	decoded, err := decode(data)
+	if err != nil {
+		return reflect.Value{}, decodeError(path, "invalid %s: %v", targetType, err)
+	}
+	if decoded == nil {
+		return reflect.Zero(targetType), nil
+	}
+
+	result := reflect.ValueOf(decoded)
+	if !result.Type().AssignableTo(targetType) {
+		return reflect.Value{}, fmt.Errorf("decoder for %s returned %s", targetType, result.Type())
+	}
+	converted := reflect.New(targetType).Elem()
+	converted.Set(result)
+	return converted, nil
+}
+
+// decodeJSONUnmarshaler 将值重新编码为JSON后交给类型的 UnmarshalJSON 解码
+func (d decoder) decodeJSONUnmarshaler(value interface{}, targetType reflect.Type, path string) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, decodeError(path, "cannot encode value: %v", err)
+	}
+	ptr := reflect.New(targetType)
+	if err := ptr.Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+		return reflect.Value{}, decodeError(path, "invalid %s: %v", targetType, err)
+	}
+	return ptr.Elem(), nil
+}
+
+// decodeMap 将JSON对象解码为map，键按 encoding/json 规则解析
+func (d decoder) decodeMap(obj map[string]interface{}, mapType reflect.Type, path string) (reflect.Value, error) {
+	result := reflect.MakeMapWithSize(mapType, len(obj))
+	keyType := mapType.Key()
+
+	for key, value := range obj {
+		keyPath := path + "/" + escapePointer(key)
+		decodedKey, err := decodeMapKey(key, keyType)
+		if err != nil {
+			return reflect.Value{}, decodeError(keyPath, "%v", err)
+		}
+		decodedValue, err := d.decodeValue(value, mapType.Elem(), keyPath)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result.SetMapIndex(decodedKey, decodedValue)
+	}
+
+	return result, nil
+}
+
+// decodeMapKey 将JSON对象的键解码为map键类型
+func decodeMapKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	if keyType.Kind() == reflect.String {
+		return reflect.ValueOf(key).Convert(keyType), nil
+	}
+
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		ptr := reflect.New(keyType)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		return ptr.Elem(), nil
+	}
+
+	switch keyType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", key, keyType)
+		}
+		return reflect.ValueOf(n).Convert(keyType), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", key, keyType)
+		}
+		return reflect.ValueOf(n).Convert(keyType), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unsupported map key type %s", keyType)
+}
+
+// decodeStruct 将JSON对象解码为结构体，字段解析规则与 encoding/json 一致
+func (d decoder) decodeStruct(obj map[string]interface{}, structType reflect.Type, path string) (reflect.Value, error) {
+	structValue := reflect.New(structType).Elem()
+
+	for _, field := range scanner.StructFields(structType) {
+		value, exists := obj[field.Name]
+		if !exists {
+			continue
+		}
+
+		fieldValue, ok := fieldByIndex(structValue, field.Index)
+		if !ok {
+			continue
+		}
+
+		decoded, err := d.decodeValue(value, field.Type, path+"/"+escapePointer(field.Name))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		fieldValue.Set(decoded)
+	}
+
+	return structValue, nil
+}
+
+// fieldByIndex 按索引路径获取字段，途经的nil嵌入指针会被初始化
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v, v.CanSet()
+}
+
+// numberString 返回JSON数值的文本形式，兼容 json.Number 以及默认值中的Go数值
+func numberString(value interface{}) (string, bool) {
+	switch n := value.(type) {
+	case json.Number:
+		return n.String(), true
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(n), 'g', -1, 32), true
+	case int:
+		return strconv.Itoa(n), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	}
+	return "", false
+}
+
+// decodeInteger 解码整数，拒绝小数并检查目标类型的取值范围
+func decodeInteger(value interface{}, targetType reflect.Type, path string) (reflect.Value, error) {
+	str, ok := numberString(value)
+	if !ok {
+		return reflect.Value{}, decodeError(path, "must be integer, got %s", jsonTypeName(value))
+	}
+
+	// 使用高精度浮点解析，兼容 1e3、2.0 这类整数值的写法
+	f, _, err := big.ParseFloat(str, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return reflect.Value{}, decodeError(path, "invalid number %q", str)
+	}
+	if !f.IsInt() {
+		return reflect.Value{}, decodeError(path, "must be integer, got %s", str)
+	}
+
+	result := reflect.New(targetType).Elem()
+	switch targetType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, acc := f.Int64()
+		if acc != big.Exact || result.OverflowInt(n) {
+			return reflect.Value{}, decodeError(path, "%s overflows %s", str, targetType)
+		}
+		result.SetInt(n)
+	default:
+		if f.Sign() < 0 {
+			return reflect.Value{}, decodeError(path, "must be >= 0, got %s", str)
+		}
+		n, acc := f.Uint64()
+		if acc != big.Exact || result.OverflowUint(n) {
+			return reflect.Value{}, decodeError(path, "%s overflows %s", str, targetType)
+		}
+		result.SetUint(n)
+	}
+
+	return result, nil
+}
+
+// decodeFloat 解码浮点数并检查目标类型的取值范围
+func decodeFloat(value interface{}, targetType reflect.Type, path string) (reflect.Value, error) {
+	str, ok := numberString(value)
+	if !ok {
+		return reflect.Value{}, decodeError(path, "must be number, got %s", jsonTypeName(value))
+	}
+
+	f, err := strconv.ParseFloat(str, targetType.Bits())
+	if err != nil {
+		return reflect.Value{}, decodeError(path, "%s overflows %s", str, targetType)
+	}
+
+	result := reflect.New(targetType).Elem()
+	result.SetFloat(f)
+	return result, nil
+}
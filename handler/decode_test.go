@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/types"
+)
+
+// accountID 以 "acct-<n>" 字符串传输的账户ID，由注册表中的解码器解析
+type accountID int
+
+// decodeAccountID 解析 "acct-<n>" 格式的账户ID
+func decodeAccountID(data json.RawMessage) (interface{}, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	var n int
+	if _, err := fmt.Sscanf(s, "acct-%d", &n); err != nil {
+		return nil, fmt.Errorf("malformed account %q", s)
+	}
+	return accountID(n), nil
+}
+
+// accountTool 返回参数 id 解码结果的测试工具
+func accountTool() types.Tool {
+	return types.Tool{
+		Name: "get_account",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"id":    map[string]interface{}{"type": "string"},
+				"peers": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		},
+		Handler: func(ctx context.Context, id accountID, peers []accountID) (string, error) {
+			return fmt.Sprintf("%d %v", int(id), peers), nil
+		},
+		ParamNames: []string{"id", "peers"},
+	}
+}
+
+// resultText 返回工具调用结果中第一个文本内容
+func resultText(t *testing.T, resp rpcResponse) string {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("error = %+v", resp.Error)
+	}
+	content, _ := resp.Result["content"].([]interface{})
+	if len(content) == 0 {
+		t.Fatalf("result = %v, want text content", resp.Result)
+	}
+	text, _ := content[0].(map[string]interface{})["text"].(string)
+	return text
+}
+
+func TestRegisteredDecoder(t *testing.T) {
+	registry := scanner.NewTypeRegistry()
+	registry.RegisterDecoder(reflect.TypeOf(accountID(0)), decodeAccountID)
+	mux := newTestMux(t, []types.Tool{accountTool()}, WithTypeRegistry(registry))
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("get_account", map[string]interface{}{
+		"id":    "acct-42",
+		"peers": []interface{}{"acct-1", "acct-2"},
+	}))
+	if got, want := resultText(t, resp), "42 [1 2]"; got != want {
+		t.Errorf("result = %q, want %q", got, want)
+	}
+
+	_, resp = postRPC(t, mux, nil, "tools/call", callParams("get_account", map[string]interface{}{
+		"id":    "acct-1",
+		"peers": []interface{}{"acct-2", "bob"},
+	}))
+	if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+		t.Fatalf("error = %+v, want %d", resp.Error, CodeInvalidParams)
+	}
+	issues, _ := resp.Error.Data["errors"].([]interface{})
+	if len(issues) != 1 {
+		t.Fatalf("errors = %v, want one issue", resp.Error.Data["errors"])
+	}
+	issue := issues[0].(map[string]interface{})
+	if issue["path"] != "/peers/1" || !strings.Contains(issue["message"].(string), `malformed account "bob"`) {
+		t.Errorf("issue = %v, want decoder error at /peers/1", issue)
+	}
+}
+
+func TestRegisteredDecoderResultType(t *testing.T) {
+	registry := scanner.NewTypeRegistry()
+	registry.RegisterDecoder(reflect.TypeOf(accountID(0)), func(data json.RawMessage) (interface{}, error) {
+		return "not an account", nil
+	})
+	mux := newTestMux(t, []types.Tool{accountTool()}, WithTypeRegistry(registry))
+
+	// 解码器返回了错误的类型属于服务端配置错误，不作为无效参数返回
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("get_account", map[string]interface{}{"id": "acct-1"}))
+	if isError, _ := resp.Result["isError"].(bool); !isError {
+		t.Fatalf("result = %v, want tool error", resp.Result)
+	}
+	if text := resultText(t, resp); !strings.Contains(text, "returned string") {
+		t.Errorf("result = %q, want decoder type error", text)
+	}
+}
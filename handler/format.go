@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/types"
+)
+
+// builtinFormatters 内置的结果格式
+var builtinFormatters = map[types.OutputFormat]types.ResultFormatter{
+	types.OutputText:        formatText,
+	types.OutputJSON:        formatJSON,
+	types.OutputJSONCompact: formatJSONCompact,
+	types.OutputMarkdown:    formatMarkdown,
+	types.OutputYAML:        formatYAML,
+}
+
+// BuiltinFormatter 获取内置的结果格式化函数
+func BuiltinFormatter(format types.OutputFormat) (types.ResultFormatter, bool) {
+	formatter, ok := builtinFormatters[format]
+	return formatter, ok
+}
+
+// WithFormatter 注册自定义结果格式，工具可通过 output=name 使用
+func WithFormatter(format types.OutputFormat, formatter types.ResultFormatter) Option {
+	return func(h *HTTPHandler) {
+		if h.formatters == nil {
+			h.formatters = make(map[types.OutputFormat]types.ResultFormatter)
+		}
+		h.formatters[format] = formatter
+	}
+}
+
+// WithDefaultFormat 设置未指定格式的工具使用的结果格式
+func WithDefaultFormat(format types.OutputFormat) Option {
+	return func(h *HTTPHandler) {
+		h.defaultFormat = format
+	}
+}
+
+// formatter 按 工具格式化函数、工具格式、服务器默认格式 的顺序选择格式化函数
+// 都未设置时，声明了 OutputSchema 的工具输出紧凑JSON，其余工具按 %v 输出
+func (h *HTTPHandler) formatter(tool *types.Tool) (types.ResultFormatter, error) {
+	if tool.Formatter != nil {
+		return tool.Formatter, nil
+	}
+
+	format := tool.OutputFormat
+	if format == "" {
+		format = h.defaultFormat
+	}
+	if format == "" {
+		if tool.OutputSchema != nil {
+			return formatJSONCompact, nil
+		}
+		return formatText, nil
+	}
+
+	if formatter, ok := h.formatters[format]; ok {
+		return formatter, nil
+	}
+	if formatter, ok := builtinFormatters[format]; ok {
+		return formatter, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// formatText 按 %v 输出
+func formatText(result interface{}) (string, error) {
+	return fmt.Sprintf("%v", result), nil
+}
+
+// formatJSON 输出缩进的JSON
+func formatJSON(result interface{}) (string, error) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatJSONCompact 输出紧凑的JSON
+func formatJSONCompact(result interface{}) (string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatYAML 按JSON字段名输出YAML，字段顺序与JSON编码一致
+func formatYAML(result interface{}) (string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := yamlNode(decoder)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// yamlNode 从JSON token流构建YAML节点，保留对象中属性的顺序
+func yamlNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			child, err := yamlNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		// 读取结束分隔符
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}, nil
+	case json.Number:
+		tag := "!!int"
+		if _, err := t.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprintf("%v", t)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// formatMarkdown 将切片输出为Markdown表格，结构体和map输出为字段、值两列的表格，其余值按 %v 输出
+// 结构体切片的列按字段声明顺序排列，map切片的列按键名排序
+func formatMarkdown(result interface{}) (string, error) {
+	rv := reflect.ValueOf(result)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", nil
+		}
+		rv = rv.Elem()
+	}
+
+	value, err := plainJSON(result)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		var columns []string
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			columns = structColumns(rv.Type().Elem())
+		}
+		return markdownTable(v, columns), nil
+	case map[string]interface{}:
+		var columns []string
+		if rv.Kind() == reflect.Struct {
+			columns = structColumns(rv.Type())
+		}
+		return markdownFields(v, columns), nil
+	default:
+		return formatText(result)
+	}
+}
+
+// structColumns 返回结构体（或结构体指针）类型按声明顺序的JSON字段名，非结构体返回nil
+func structColumns(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := scanner.StructFields(t)
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Name
+	}
+	return columns
+}
+
+// markdownTable 将对象数组输出为表格，元素不是对象时输出为列表
+func markdownTable(rows []interface{}, columns []string) string {
+	objects := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		obj, ok := row.(map[string]interface{})
+		if !ok && row != nil {
+			return markdownList(rows)
+		}
+		objects = append(objects, obj)
+	}
+
+	if columns == nil {
+		seen := make(map[string]bool)
+		for _, obj := range objects {
+			for key := range obj {
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+	if len(columns) == 0 {
+		return markdownList(rows)
+	}
+
+	var b strings.Builder
+	writeRow(&b, columns)
+	separators := make([]string, len(columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	writeRow(&b, separators)
+
+	for _, obj := range objects {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = markdownCell(obj[column])
+		}
+		writeRow(&b, cells)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// markdownFields 将单个对象输出为字段、值两列的表格
+func markdownFields(obj map[string]interface{}, columns []string) string {
+	if columns == nil {
+		for key := range obj {
+			columns = append(columns, key)
+		}
+		sort.Strings(columns)
+	}
+
+	var b strings.Builder
+	writeRow(&b, []string{"Field", "Value"})
+	writeRow(&b, []string{"---", "---"})
+	for _, column := range columns {
+		value, ok := obj[column]
+		if !ok {
+			continue
+		}
+		writeRow(&b, []string{markdownCell(column), markdownCell(value)})
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// markdownList 将数组输出为无序列表
+func markdownList(items []interface{}) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = "- " + markdownCell(item)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeRow 写入一行表格
+func writeRow(b *strings.Builder, cells []string) {
+	b.WriteString("| ")
+	b.WriteString(strings.Join(cells, " | "))
+	b.WriteString(" |\n")
+}
+
+// markdownCell 将值转换为单元格文本，嵌套值输出为紧凑JSON，并转义竖线和换行
+func markdownCell(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		text = v
+	case bool, int64, float64:
+		text = fmt.Sprintf("%v", v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			text = fmt.Sprintf("%v", v)
+		} else {
+			text = string(data)
+		}
+	}
+
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// plainJSON 将值按JSON编码后解码为通用值，整数保持为 int64，其余数值为 float64
+func plainJSON(result interface{}) (interface{}, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := unmarshalUseNumber(data, &value); err != nil {
+		return nil, err
+	}
+	return convertNumbers(value), nil
+}
+
+// convertNumbers 递归地将 json.Number 转换为 int64 或 float64
+func convertNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+	}
+	return value
+}
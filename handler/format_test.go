@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/types"
+)
+
+// formatRow 输出为表格行的结构体，列按字段声明顺序排列
+type formatRow struct {
+	ID   int64    `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+	Note *string  `json:"note"`
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	note := "n"
+	tests := []struct {
+		name   string
+		result interface{}
+		want   string
+	}{
+		{
+			name: "struct slice",
+			result: []formatRow{
+				{ID: 1, Name: "a|b", Tags: []string{"x"}},
+				{ID: 2, Name: "line1\nline2", Note: &note},
+			},
+			want: "| id | name | tags | note |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| 1 | a\\|b | [\"x\"] |  |\n" +
+				"| 2 | line1<br>line2 |  | n |",
+		},
+		{
+			name: "map slice",
+			result: []map[string]interface{}{
+				{"b": 1.5, "a": true},
+				{"c": "x"},
+			},
+			want: "| a | b | c |\n" +
+				"| --- | --- | --- |\n" +
+				"| true | 1.5 |  |\n" +
+				"|  |  | x |",
+		},
+		{
+			name:   "single struct",
+			result: &formatRow{ID: 7, Name: "bob"},
+			want:   "| Field | Value |\n| --- | --- |\n| id | 7 |\n| name | bob |\n| note |  |",
+		},
+		{
+			name:   "scalar slice",
+			result: []int{1, 2},
+			want:   "- 1\n- 2",
+		},
+		{
+			name:   "empty struct slice",
+			result: []formatRow{},
+			want:   "| id | name | tags | note |\n| --- | --- | --- | --- |",
+		},
+		{name: "nil pointer", result: (*formatRow)(nil), want: ""},
+		{name: "scalar", result: 42, want: "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatMarkdown(tt.result)
+			if err != nil {
+				t.Fatalf("formatMarkdown() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		name   string
+		result interface{}
+		want   string
+	}{
+		{
+			name:   "struct keeps field order",
+			result: formatRow{ID: 9007199254740993, Name: "bob", Tags: []string{"a", "b"}},
+			want:   "id: 9007199254740993\nname: bob\ntags:\n  - a\n  - b\nnote: null",
+		},
+		{
+			name:   "numbers",
+			result: map[string]interface{}{"float": 1.5, "int": 2},
+			want:   "float: 1.5\nint: 2",
+		},
+		{
+			name:   "strings that look like other types stay strings",
+			result: []string{"true", "123", "null"},
+			want:   "- \"true\"\n- \"123\"\n- \"null\"",
+		},
+		{name: "scalar", result: "text", want: "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatYAML(tt.result)
+			if err != nil {
+				t.Fatalf("formatYAML() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatYAML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatterPrecedence(t *testing.T) {
+	custom := func(result interface{}) (string, error) { return "custom", nil }
+	upper := func(result interface{}) (string, error) { return "upper", nil }
+	h := NewHTTPHandler(&testServer{},
+		WithDefaultFormat(types.OutputYAML),
+		WithFormatter("upper", upper),
+		WithFormatter(types.OutputJSON, upper),
+	)
+
+	tests := []struct {
+		name string
+		tool types.Tool
+		want string
+	}{
+		{"formatter before output format", types.Tool{Formatter: custom, OutputFormat: types.OutputMarkdown}, "custom"},
+		{"output format before default", types.Tool{OutputFormat: types.OutputJSONCompact}, `{"a":1}`},
+		{"registered format", types.Tool{OutputFormat: "upper"}, "upper"},
+		{"registered format overrides builtin", types.Tool{OutputFormat: types.OutputJSON}, "upper"},
+		{"server default", types.Tool{}, "a: 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := h.formatter(&tt.tool)
+			if err != nil {
+				t.Fatalf("formatter() error = %v", err)
+			}
+			got, err := formatter(map[string]interface{}{"a": 1})
+			if err != nil || got != tt.want {
+				t.Errorf("formatter() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	plain := NewHTTPHandler(&testServer{})
+	if formatter, _ := plain.formatter(&types.Tool{OutputSchema: map[string]interface{}{"type": "object"}}); formatter == nil {
+		t.Fatal("formatter() of a tool with outputSchema = nil")
+	} else if got, _ := formatter(map[string]interface{}{"a": 1}); got != `{"a":1}` {
+		t.Errorf("tool with outputSchema = %q, want compact JSON", got)
+	}
+	if formatter, _ := plain.formatter(&types.Tool{}); formatter == nil {
+		t.Fatal("formatter() of a plain tool = nil")
+	} else if got, _ := formatter(map[string]interface{}{"a": 1}); got != "map[a:1]" {
+		t.Errorf("plain tool = %q, want %%v output", got)
+	}
+	if _, err := plain.formatter(&types.Tool{OutputFormat: "html"}); err == nil || !strings.Contains(err.Error(), "html") {
+		t.Errorf("formatter() of unknown format error = %v, want unknown format", err)
+	}
+}
+
+func TestToolsCallUsesToolFormatter(t *testing.T) {
+	tool := echoTool("echo")
+	tool.OutputFormat = types.OutputJSON
+	tool.Formatter = func(result interface{}) (string, error) {
+		return "formatted " + result.(string), nil
+	}
+	mux := newTestMux(t, []types.Tool{tool}, WithDefaultFormat(types.OutputYAML))
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	if got := resultText(t, resp); got != "formatted hi" {
+		t.Errorf("result = %q, want formatted hi", got)
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"nacos-mcp-go/types"
+)
+
+// testServer 实现 types.ServerInterface 的测试服务器
+type testServer struct {
+	tools []types.Tool
+}
+
+func (s *testServer) GetName() string                            { return "test" }
+func (s *testServer) GetNamespace() string                       { return "" }
+func (s *testServer) GetGroup() string                           { return "DEFAULT_GROUP" }
+func (s *testServer) GetAddress() (string, int)                  { return "127.0.0.1", 8080 }
+func (s *testServer) GetProtocol() types.Protocol                { return types.ProtocolStreamHTTP }
+func (s *testServer) GetTools() []types.Tool                     { return s.tools }
+func (s *testServer) GetMetadata() map[string]string             { return nil }
+func (s *testServer) GetSecuritySchemes() []types.SecurityScheme { return nil }
+func (s *testServer) IsRunning() bool                            { return true }
+
+// echoTool 返回参数 message 的测试工具
+func echoTool(name string) types.Tool {
+	return types.Tool{
+		Name: name,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"message": map[string]interface{}{"type": "string"},
+			},
+		},
+		Invoke: func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+			return arguments["message"], nil
+		},
+	}
+}
+
+// newTestMux 创建注册了 tools 的处理器路由，日志输出被丢弃
+func newTestMux(t *testing.T, tools []types.Tool, opts ...Option) *http.ServeMux {
+	t.Helper()
+	opts = append([]Option{WithLogger(log.New(io.Discard, "", 0))}, opts...)
+	h := NewHTTPHandler(&testServer{tools: tools}, opts...)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux
+}
+
+// rpcResponse 解码后的JSON-RPC响应
+type rpcResponse struct {
+	Result map[string]interface{} `json:"result"`
+	Error  *struct {
+		Code    int                    `json:"code"`
+		Message string                 `json:"message"`
+		Data    map[string]interface{} `json:"data"`
+	} `json:"error"`
+}
+
+// postRPC 向 /mcp 发送JSON-RPC请求，header 为附加的请求头
+func postRPC(t *testing.T, handler http.Handler, header http.Header, method string, params interface{}) (*httptest.ResponseRecorder, rpcResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var resp rpcResponse
+	if w.Code != http.StatusUnauthorized {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response %q: %v", w.Body.String(), err)
+		}
+	}
+	return w, resp
+}
+
+// callParams 返回 tools/call 的参数
+func callParams(name string, arguments map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"name": name, "arguments": arguments}
+}
+
+func TestToolsListAnnotations(t *testing.T) {
+	readOnly, destructive := true, false
+	annotated := echoTool("get_user")
+	annotated.Title = "Get user"
+	annotated.Annotations = &types.ToolAnnotations{ReadOnlyHint: &readOnly, DestructiveHint: &destructive}
+	mux := newTestMux(t, []types.Tool{annotated, echoTool("echo")})
+
+	_, resp := postRPC(t, mux, nil, "tools/list", nil)
+	if resp.Error != nil {
+		t.Fatalf("error = %+v", resp.Error)
+	}
+	tools, _ := resp.Result["tools"].([]interface{})
+	if len(tools) != 2 {
+		t.Fatalf("tools = %v, want 2 tools", resp.Result["tools"])
+	}
+
+	got := tools[0].(map[string]interface{})
+	if got["title"] != "Get user" {
+		t.Errorf("title = %v, want Get user", got["title"])
+	}
+	want := map[string]interface{}{"readOnlyHint": true, "destructiveHint": false}
+	if !reflect.DeepEqual(got["annotations"], want) {
+		t.Errorf("annotations = %v, want %v", got["annotations"], want)
+	}
+	for _, key := range []string{"title", "annotations"} {
+		if _, ok := tools[1].(map[string]interface{})[key]; ok {
+			t.Errorf("tool without %s lists %s", key, key)
+		}
+	}
+}
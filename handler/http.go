@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,28 +11,104 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/audit"
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/ratelimit"
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/tracing"
 	"nacos-mcp-go/types"
 )
 
+// ErrToolNotFound 调用的工具不存在
+var ErrToolNotFound = errors.New("tool not found")
+
+// ScannedNameMetaKey 工具被重命名时，tools/list 的 _meta 中记录扫描得到的工具名的键，供生成清单使用
+const ScannedNameMetaKey = "nacos-mcp-go/scannedName"
+
 // HTTPHandler 封装 MCP HTTP 接口
 type HTTPHandler struct {
-	server types.ServerInterface
-	mu     sync.RWMutex
+	server           types.ServerInterface
+	registry         *scanner.TypeRegistry
+	formatters       map[types.OutputFormat]types.ResultFormatter
+	defaultFormat    types.OutputFormat
+	middleware       []types.ToolMiddleware
+	authenticators   []auth.Authenticator
+	resourceMetadata *auth.ResourceMetadata
+	metrics          *metrics.Metrics
+	metricsPath      string
+	tracerProvider   trace.TracerProvider
+	defaultTimeout   time.Duration
+	maxConcurrency   int
+	queueTimeout     time.Duration
+	slots            semaphore            // 所有工具共享的并发名额
+	toolSlots        map[string]semaphore // 各工具的并发名额
+	limitMu          sync.Mutex
+	logger           *log.Logger
+	rateLimitStore   ratelimit.Store
+	principalLimit   types.RateLimit
+	sessionLimit     types.RateLimit
+	cache            *resultCache
+	auditSinks       []audit.Sink
+	sessions         *sessionStore
+	sessionTimeout   time.Duration
+	maxSessions      int
+	mu               sync.RWMutex
+}
+
+// Option 处理器配置选项
+type Option func(*HTTPHandler)
+
+// WithTypeRegistry 设置类型注册表，调用时使用其中注册的解码器
+func WithTypeRegistry(registry *scanner.TypeRegistry) Option {
+	return func(h *HTTPHandler) {
+		h.registry = registry
+	}
 }
 
 // NewHTTPHandler 创建新的处理器
-func NewHTTPHandler(server types.ServerInterface) *HTTPHandler {
-	return &HTTPHandler{
+func NewHTTPHandler(server types.ServerInterface, opts ...Option) *HTTPHandler {
+	h := &HTTPHandler{
 		server: server,
+		logger: log.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+	if h.rateLimitStore == nil {
+		h.rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	if h.cache == nil {
+		h.cache = newResultCache(DefaultCacheSize)
+	}
+	h.sessions = newSessionStore(h.sessionTimeout, h.maxSessions)
+
+	return h
 }
 
 // RegisterRoutes 注册 MCP 路由到 http.ServeMux
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/mcp/tools", h.listTools)
-	mux.HandleFunc("/mcp/tools/", h.invokeTool)
-	mux.HandleFunc("/mcp/info", h.serverInfo)
+	mux.HandleFunc("/mcp", h.authenticate(h.handleMCP))
+	mux.HandleFunc("/mcp/tools", h.authenticate(h.listTools))
+	mux.HandleFunc("/mcp/tools/", h.authenticate(h.invokeTool))
+	mux.HandleFunc("/mcp/info", h.authenticate(h.serverInfo))
+
+	if h.metrics != nil && h.metricsPath != "" {
+		mux.Handle(h.metricsPath, h.metrics.Handler())
+	}
+
+	if h.resourceMetadata != nil {
+		mux.HandleFunc(auth.ResourceMetadataPath, h.protectedResource)
+		if path := h.resourceMetadata.MetadataPath(); path != auth.ResourceMetadataPath {
+			mux.HandleFunc(path, h.protectedResource)
+		}
+	}
 }
 
 // serverInfo 处理 /mcp/info - 返回服务器信息
@@ -53,7 +131,7 @@ func (h *HTTPHandler) serverInfo(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(info); err != nil {
-		log.Printf("Error encoding server info: %v", err)
+		h.logger.Printf("Error encoding server info: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
@@ -65,27 +143,13 @@ func (h *HTTPHandler) listTools(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.mu.RLock()
-	tools := h.server.GetTools()
-	h.mu.RUnlock()
-
-	// 转换为标准MCP工具格式
-	mcpTools := make([]map[string]interface{}, len(tools))
-	for i, tool := range tools {
-		mcpTools[i] = map[string]interface{}{
-			"name":        tool.Name,
-			"description": tool.Description,
-			"inputSchema": tool.InputSchema,
-		}
-	}
-
 	response := map[string]interface{}{
-		"tools": mcpTools,
+		"tools": h.mcpTools(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding tools list: %v", err)
+		h.logger.Printf("Error encoding tools list: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
@@ -105,6 +169,7 @@ func (h *HTTPHandler) invokeTool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	toolName := parts[0]
+	r = h.bindSession(r)
 
 	// 读取请求体
 	body, err := io.ReadAll(r.Body)
@@ -117,220 +182,276 @@ func (h *HTTPHandler) invokeTool(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Arguments map[string]interface{} `json:"arguments"`
 	}
-	if err := json.Unmarshal(body, &req); err != nil {
+	if err := unmarshalUseNumber(body, &req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
 	// 查找并调用工具
-	result, err := h.callTool(toolName, req.Arguments)
+	if tool, ok := h.findTool(toolName); ok {
+		h.extendWriteDeadline(w, tool)
+	}
+	ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	result, err := h.callTool(ctx, toolName, req.Arguments)
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		// 参数校验失败，返回与 tools/call 相同结构的错误
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": &RPCError{
+				Code:    CodeInvalidParams,
+				Message: "Invalid params",
+				Data:    map[string]interface{}{"errors": validationErr.Issues},
+			},
+		})
+		return
+	}
+	if errors.Is(err, ErrToolNotFound) {
+		http.Error(w, fmt.Sprintf("Not Found: %v", err), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, auth.ErrUnauthenticated) {
+		h.writeUnauthorized(w, err)
+		return
+	}
+	if errors.Is(err, auth.ErrForbidden) {
+		var scopeErr *ScopeError
+		if errors.As(err, &scopeErr) {
+			w.Header().Set("WWW-Authenticate", h.challenge("insufficient_scope", scopeErr.Scopes))
+		}
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		h.writeRateLimited(w, rateErr.retryAfterSeconds())
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": rateLimitedError(rateErr)})
+		return
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": panicError(panicErr)})
+		return
+	}
+	if errors.Is(err, ErrToolBusy) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, ErrToolTimeout) {
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
 	if err != nil {
-		log.Printf("Error calling tool %s: %v", toolName, err)
+		h.logger.Printf("Error calling tool %s: %v", toolName, err)
 		http.Error(w, fmt.Sprintf("Tool execution failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// 返回结果
 	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// mcpTools 返回标准MCP格式的工具列表
+func (h *HTTPHandler) mcpTools() []map[string]interface{} {
+	h.mu.RLock()
+	tools := h.server.GetTools()
+	h.mu.RUnlock()
+
+	mcpTools := make([]map[string]interface{}, len(tools))
+	for i, tool := range tools {
+		mcpTools[i] = map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"inputSchema": tool.InputSchema,
+		}
+		if tool.Title != "" {
+			mcpTools[i]["title"] = tool.Title
+		}
+		if tool.OutputSchema != nil {
+			mcpTools[i]["outputSchema"] = tool.OutputSchema
+		}
+		if tool.Annotations != nil {
+			mcpTools[i]["annotations"] = tool.Annotations
+		}
+		if tool.ScannedName != "" && tool.ScannedName != tool.Name {
+			mcpTools[i]["_meta"] = map[string]interface{}{ScannedNameMetaKey: tool.ScannedName}
+		}
+	}
+	return mcpTools
+}
+
+// toolResult 将工具函数的返回值封装为MCP工具调用结果
+// 文本内容由工具选择的格式化函数生成，声明了 OutputSchema 的工具同时返回 structuredContent
+func (h *HTTPHandler) toolResult(tool *types.Tool, result interface{}) (map[string]interface{}, error) {
+	formatter, err := h.formatter(tool)
+	if err != nil {
+		return nil, err
+	}
+	text, err := formatter(result)
+	if err != nil {
+		return nil, fmt.Errorf("format result failed: %w", err)
+	}
+
 	response := map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("%v", result),
+				"text": text,
 			},
 		},
 	}
+	if tool.OutputSchema != nil && !isNil(result) {
+		response["structuredContent"] = result
+	}
+	return response, nil
+}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// isNil 判断值是否为nil或nil指针
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
 	}
+	return false
 }
 
-// callTool 调用指定的工具
-func (h *HTTPHandler) callTool(toolName string, arguments map[string]interface{}) (interface{}, error) {
+// findTool 按名称查找工具
+func (h *HTTPHandler) findTool(name string) (*types.Tool, bool) {
 	h.mu.RLock()
 	tools := h.server.GetTools()
 	h.mu.RUnlock()
 
-	// 查找工具
-	var targetTool *types.Tool
 	for i := range tools {
-		if tools[i].Name == toolName {
-			targetTool = &tools[i]
-			break
+		if tools[i].Name == name {
+			return &tools[i], true
 		}
 	}
+	return nil, false
+}
 
-	if targetTool == nil {
-		return nil, fmt.Errorf("tool '%s' not found", toolName)
+// callTool 调用指定的工具，返回MCP工具调用结果
+func (h *HTTPHandler) callTool(ctx context.Context, toolName string, arguments map[string]interface{}) (map[string]interface{}, error) {
+	targetTool, ok := h.findTool(toolName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, toolName)
 	}
 
-	// 调用工具函数
-	return h.invokeHandler(targetTool.Handler, arguments)
+	ctx, span := h.startToolSpan(ctx, targetTool)
+	start := time.Now()
+	event := h.auditEvent(ctx, targetTool, arguments, start)
+	result, err := h.executeTool(ctx, targetTool, arguments)
+	h.observeToolCall(targetTool, arguments, start, result, err)
+	h.recordAudit(ctx, event, err)
+	endToolSpan(span, err)
+	return result, err
 }
 
-// invokeHandler 通过反射调用处理器函数
-func (h *HTTPHandler) invokeHandler(handler interface{}, arguments map[string]interface{}) (interface{}, error) {
-	handlerValue := reflect.ValueOf(handler)
-	handlerType := reflect.TypeOf(handler)
+// executeTool 检查权限、校验参数后经过中间件链调用工具，并格式化结果
+func (h *HTTPHandler) executeTool(ctx context.Context, targetTool *types.Tool, arguments map[string]interface{}) (map[string]interface{}, error) {
+	// 校验参数前检查访问权限
+	if err := authorize(ctx, targetTool); err != nil {
+		return nil, err
+	}
+
+	// 校验参数前检查限流，被拒绝的调用不消耗校验和执行的开销
+	if err := h.rateLimit(ctx, targetTool); err != nil {
+		return nil, err
+	}
+
+	// 为缺失的可选参数填充默认值
+	arguments = applyDefaults(targetTool.InputSchema, arguments)
 
-	if handlerType.Kind() != reflect.Func {
-		return nil, fmt.Errorf("handler is not a function")
+	// 调用前按 InputSchema 校验参数
+	if err := validateArguments(targetTool.InputSchema, arguments); err != nil {
+		return nil, err
+	}
+
+	// 在超时和并发限制下经过中间件链调用工具函数，开启缓存的工具在链的最内层查找缓存
+	req := &types.ToolRequest{
+		Tool:      targetTool,
+		Arguments: arguments,
+		Session:   sessionFromContext(ctx),
+	}
+	// 工具函数、中间件和格式化函数中的panic在本次调用内恢复
+	result, err := h.limit(ctx, targetTool, func(ctx context.Context) (interface{}, error) {
+		return h.recoverPanic(ctx, targetTool, func() (interface{}, error) {
+			result, err := h.chain(targetTool, h.cachedInvoke)(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return h.toolResult(targetTool, result)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+// invokeHandler 通过反射调用处理器函数
+// 第一个参数为 context.Context 时传入请求上下文，返回的 error 作为工具执行失败处理，流式返回值被汇总为切片
+func (h *HTTPHandler) invokeHandler(ctx context.Context, tool *types.Tool, arguments map[string]interface{}) (interface{}, error) {
+	handlerValue := reflect.ValueOf(tool.Handler)
+	sig, err := scanner.ParseSignature(reflect.TypeOf(tool.Handler))
+	if err != nil {
+		return nil, err
 	}
 
 	// 准备参数
-	numIn := handlerType.NumIn()
-	args := make([]reflect.Value, numIn)
+	args := make([]reflect.Value, 0, len(sig.Params)+1)
+	if sig.Context {
+		args = append(args, reflect.ValueOf(&ctx).Elem())
+	}
+	structArgument := scanner.IsStructArgument(handlerValue.Type(), h.registry)
 
 	// 根据函数签名转换参数
-	for i := 0; i < numIn; i++ {
-		paramType := handlerType.In(i)
-
-		// 尝试从arguments中获取参数
+	for i, paramType := range sig.Params {
+		// 从arguments中获取参数
 		var paramValue interface{}
-		if i == 0 && numIn == 1 {
-			// 单参数情况，可能是整个arguments对象
-			if paramType.Kind() == reflect.Struct {
-				paramValue = arguments
-			} else {
-				// 尝试获取第一个参数值
-				for _, v := range arguments {
-					paramValue = v
-					break
-				}
-			}
+		var paramPath string
+		if structArgument {
+			// 单个结构体参数，整个arguments对象即为该参数
+			paramValue = arguments
 		} else {
-			// 多参数情况，按顺序获取
+			// 按schema中的参数名获取
 			paramName := fmt.Sprintf("param%d", i+1)
-			if val, exists := arguments[paramName]; exists {
-				paramValue = val
-			} else {
-				// 尝试按参数名获取
-				for key, val := range arguments {
-					if strings.EqualFold(key, paramName) {
-						paramValue = val
-						break
-					}
-				}
+			if i < len(tool.ParamNames) && tool.ParamNames[i] != "" {
+				paramName = tool.ParamNames[i]
 			}
+			paramValue = arguments[paramName]
+			paramPath = "/" + escapePointer(paramName)
 		}
 
-		// 转换参数类型
-		convertedValue, err := h.convertValue(paramValue, paramType)
+		// 解码参数
+		decodedValue, err := decoder{registry: h.registry}.decodeValue(paramValue, paramType, paramPath)
 		if err != nil {
-			return nil, fmt.Errorf("convert parameter %d failed: %w", i, err)
+			return nil, err
 		}
-		args[i] = convertedValue
+		args = append(args, decodedValue)
 	}
 
 	// 调用函数
 	results := handlerValue.Call(args)
 
-	// 处理返回值
-	if len(results) == 0 {
-		return nil, nil
-	}
-
-	// 返回第一个结果
-	result := results[0].Interface()
-	return result, nil
-}
-
-// convertValue 转换参数值到指定类型
-func (h *HTTPHandler) convertValue(value interface{}, targetType reflect.Type) (reflect.Value, error) {
-	if value == nil {
-		return reflect.Zero(targetType), nil
-	}
-
-	valueType := reflect.TypeOf(value)
-
-	// 如果类型匹配，直接返回
-	if valueType == targetType {
-		return reflect.ValueOf(value), nil
-	}
-
-	// 处理基本类型转换
-	switch targetType.Kind() {
-	case reflect.String:
-		return reflect.ValueOf(fmt.Sprintf("%v", value)), nil
-	case reflect.Int, reflect.Int32, reflect.Int64:
-		if num, ok := value.(float64); ok {
-			return reflect.ValueOf(int(num)).Convert(targetType), nil
-		}
-		if num, ok := value.(int); ok {
-			return reflect.ValueOf(num).Convert(targetType), nil
-		}
-	case reflect.Float32, reflect.Float64:
-		if num, ok := value.(float64); ok {
-			return reflect.ValueOf(num).Convert(targetType), nil
-		}
-		if num, ok := value.(int); ok {
-			return reflect.ValueOf(float64(num)).Convert(targetType), nil
-		}
-	case reflect.Bool:
-		if b, ok := value.(bool); ok {
-			return reflect.ValueOf(b), nil
-		}
-	case reflect.Slice:
-		if slice, ok := value.([]interface{}); ok {
-			elemType := targetType.Elem()
-			result := reflect.MakeSlice(targetType, len(slice), len(slice))
-			for i, item := range slice {
-				convertedItem, err := h.convertValue(item, elemType)
-				if err != nil {
-					return reflect.Value{}, err
-				}
-				result.Index(i).Set(convertedItem)
-			}
-			return result, nil
-		}
-	case reflect.Struct:
-		// 处理结构体参数
-		if argMap, ok := value.(map[string]interface{}); ok {
-			return h.mapToStruct(argMap, targetType)
+	// 处理返回值，最后一个返回值为 error 时优先返回错误
+	if sig.Error {
+		if errValue := results[len(results)-1]; !errValue.IsNil() {
+			return nil, errValue.Interface().(error)
 		}
 	}
-
-	// 尝试直接转换
-	valueReflect := reflect.ValueOf(value)
-	if valueReflect.Type().ConvertibleTo(targetType) {
-		return valueReflect.Convert(targetType), nil
-	}
-
-	return reflect.Zero(targetType), fmt.Errorf("cannot convert %T to %s", value, targetType)
-}
-
-// mapToStruct 将map转换为结构体
-func (h *HTTPHandler) mapToStruct(argMap map[string]interface{}, structType reflect.Type) (reflect.Value, error) {
-	structValue := reflect.New(structType).Elem()
-
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		fieldValue := structValue.Field(i)
-
-		if !fieldValue.CanSet() {
-			continue
-		}
-
-		// 获取字段名（优先使用json tag）
-		fieldName := field.Name
-		if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] != "" {
-				fieldName = parts[0]
-			}
-		}
-
-		// 从map中获取值
-		if value, exists := argMap[fieldName]; exists {
-			convertedValue, err := h.convertValue(value, field.Type)
-			if err != nil {
-				return reflect.Value{}, fmt.Errorf("convert field %s failed: %w", fieldName, err)
-			}
-			fieldValue.Set(convertedValue)
-		}
+	if sig.Result == nil {
+		return nil, nil
 	}
-
-	return structValue, nil
+	return results[0].Interface(), nil
 }
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"nacos-mcp-go/auth"
+)
+
+// JSON-RPC 2.0 错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// LatestProtocolVersion 服务端支持的最新MCP协议版本
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions 服务端支持的MCP协议版本
+var supportedProtocolVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// jsonrpcRequest JSON-RPC请求，ID为空表示通知
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// jsonrpcResponse JSON-RPC响应
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError JSON-RPC错误对象
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error 实现error接口
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// handleMCP 处理 /mcp - MCP JSON-RPC 接口
+func (h *HTTPHandler) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r = h.bindSession(r)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad Request: Cannot read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var req jsonrpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		h.writeRPC(w, nil, nil, &RPCError{Code: CodeParseError, Message: "Parse error"})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		h.writeRPC(w, req.ID, nil, &RPCError{Code: CodeInvalidRequest, Message: "Invalid Request"})
+		return
+	}
+
+	// 通知无需响应
+	if len(req.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	ctx, span := h.startRequestSpan(r, &req)
+
+	// 客户端接受SSE且提供了 progressToken 时，流式工具的每个元素以进度通知推送
+	if req.Method == "tools/call" && acceptsEventStream(r) {
+		if name, token, ok := callProgressToken(req.Params); ok {
+			if tool, ok := h.isStreamingTool(name); ok {
+				rpcErr := h.serveStreamingCall(ctx, w, &req, tool, token)
+				endRequestSpan(span, rpcErr)
+				return
+			}
+		}
+	}
+
+	if req.Method == "tools/call" {
+		if tool, ok := h.findTool(callName(req.Params)); ok {
+			h.extendWriteDeadline(w, tool)
+		}
+	}
+
+	result, rpcErr := h.dispatch(ctx, &req)
+	endRequestSpan(span, rpcErr)
+	if rpcErr != nil && rpcErr.Code == CodeForbidden {
+		h.writeForbidden(w, requiredScopes(rpcErr))
+	}
+	if rpcErr != nil && rpcErr.Code == CodeRateLimited {
+		h.writeRateLimited(w, retryAfter(rpcErr))
+	}
+	if req.Method == "initialize" && rpcErr == nil {
+		h.issueSession(w)
+	}
+	h.writeRPC(w, req.ID, result, rpcErr)
+}
+
+// dispatch 按方法名分发JSON-RPC请求
+func (h *HTTPHandler) dispatch(ctx context.Context, req *jsonrpcRequest) (interface{}, *RPCError) {
+	switch req.Method {
+	case "initialize":
+		return h.initialize(req.Params)
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": h.mcpTools()}, nil
+	case "tools/call":
+		return h.rpcCallTool(ctx, req.Params)
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("Method not found: %s", req.Method)}
+	}
+}
+
+// initialize 处理 initialize 请求，协商协议版本
+func (h *HTTPHandler) initialize(raw json.RawMessage) (interface{}, *RPCError) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "Invalid params"}
+		}
+	}
+
+	version := LatestProtocolVersion
+	if supportedProtocolVersions[params.ProtocolVersion] {
+		version = params.ProtocolVersion
+	}
+
+	h.mu.RLock()
+	serverVersion := h.server.GetMetadata()["version"]
+	serverInfo := map[string]interface{}{
+		"name": h.server.GetName(),
+	}
+	h.mu.RUnlock()
+	if serverVersion == "" {
+		serverVersion = "1.0.0"
+	}
+	serverInfo["version"] = serverVersion
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{
+				"listChanged": false,
+			},
+		},
+		"serverInfo": serverInfo,
+	}, nil
+}
+
+// rpcCallTool 处理 tools/call 请求
+// 参数校验失败返回 -32602 错误，工具自身执行失败以 isError 结果返回
+func (h *HTTPHandler) rpcCallTool(ctx context.Context, raw json.RawMessage) (interface{}, *RPCError) {
+	var params struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := unmarshalUseNumber(raw, &params); err != nil {
+		return nil, &RPCError{
+			Code:    CodeInvalidParams,
+			Message: "Invalid params",
+			Data: map[string]interface{}{
+				"errors": []ValidationIssue{{Path: "/arguments", Message: "must be an object"}},
+			},
+		}
+	}
+
+	result, err := h.callTool(ctx, params.Name, params.Arguments)
+	var validationErr *ValidationError
+	var panicErr *PanicError
+	var rateErr *RateLimitError
+	switch {
+	case errors.As(err, &validationErr):
+		return nil, &RPCError{
+			Code:    CodeInvalidParams,
+			Message: "Invalid params",
+			Data:    map[string]interface{}{"errors": validationErr.Issues},
+		}
+	case errors.Is(err, ErrToolNotFound):
+		return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("Unknown tool: %s", params.Name)}
+	case errors.Is(err, auth.ErrUnauthenticated), errors.Is(err, auth.ErrForbidden):
+		return nil, forbiddenError(err)
+	case errors.As(err, &panicErr):
+		return nil, panicError(panicErr)
+	case errors.As(err, &rateErr):
+		return nil, rateLimitedError(rateErr)
+	case err != nil:
+		h.logger.Printf("Error calling tool %s: %v", params.Name, err)
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": err.Error()},
+			},
+			"isError": true,
+		}, nil
+	}
+
+	return result, nil
+}
+
+// callName 读取 tools/call 请求的工具名
+func callName(params json.RawMessage) string {
+	var call struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return ""
+	}
+	return call.Name
+}
+
+// writeRPC 写入JSON-RPC响应
+func (h *HTTPHandler) writeRPC(w http.ResponseWriter, id json.RawMessage, result interface{}, rpcErr *RPCError) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	response := jsonrpcResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+		Error:   rpcErr,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Printf("Error encoding JSON-RPC response: %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"nacos-mcp-go/types"
+)
+
+var (
+	// ErrToolTimeout 工具调用超过超时时间
+	ErrToolTimeout = errors.New("tool call timed out")
+	// ErrToolBusy 并发调用数已满，排队超时
+	ErrToolBusy = errors.New("tool is busy")
+)
+
+// writeDeadlineGrace 工具超时后写入响应的宽限时间
+const writeDeadlineGrace = 5 * time.Second
+
+// WithDefaultTimeout 设置工具调用的默认超时，工具未单独设置时使用，为0时不限制
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(h *HTTPHandler) {
+		h.defaultTimeout = timeout
+	}
+}
+
+// WithMaxConcurrency 设置所有工具同时执行的最大调用数，为0时不限制
+func WithMaxConcurrency(n int) Option {
+	return func(h *HTTPHandler) {
+		h.maxConcurrency = n
+	}
+}
+
+// WithQueueTimeout 设置并发数已满时调用排队等待的最长时间，为0时一直等待到调用超时或客户端断开
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(h *HTTPHandler) {
+		h.queueTimeout = timeout
+	}
+}
+
+// semaphore 限制并发调用数的信号量
+type semaphore chan struct{}
+
+// acquire 获取一个执行名额，等待超过 queueTimeout 时返回 ErrToolBusy，上下文结束时返回其错误
+func (s semaphore) acquire(ctx context.Context, queueTimeout time.Duration) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	default:
+	}
+
+	var expired <-chan time.Time
+	if queueTimeout > 0 {
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-expired:
+		return ErrToolBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 归还执行名额
+func (s semaphore) release() {
+	<-s
+}
+
+// toolTimeout 返回工具的调用超时
+func (h *HTTPHandler) toolTimeout(tool *types.Tool) time.Duration {
+	if tool.Timeout > 0 {
+		return tool.Timeout
+	}
+	return h.defaultTimeout
+}
+
+// toolSemaphore 返回工具的并发信号量，工具未限制并发时返回nil
+func (h *HTTPHandler) toolSemaphore(tool *types.Tool) semaphore {
+	if tool.MaxConcurrency <= 0 {
+		return nil
+	}
+
+	h.limitMu.Lock()
+	defer h.limitMu.Unlock()
+	sem, ok := h.toolSlots[tool.Name]
+	if !ok {
+		if h.toolSlots == nil {
+			h.toolSlots = make(map[string]semaphore)
+		}
+		sem = make(semaphore, tool.MaxConcurrency)
+		h.toolSlots[tool.Name] = sem
+	}
+	return sem
+}
+
+// globalSemaphore 返回所有工具共享的并发信号量，未限制并发时返回nil
+func (h *HTTPHandler) globalSemaphore() semaphore {
+	if h.maxConcurrency <= 0 {
+		return nil
+	}
+
+	h.limitMu.Lock()
+	defer h.limitMu.Unlock()
+	if h.slots == nil {
+		h.slots = make(semaphore, h.maxConcurrency)
+	}
+	return h.slots
+}
+
+// limit 在超时和并发限制下执行工具调用
+// 排队时间计入调用超时；超时后立即返回错误，工具函数在后台继续执行直到返回，期间仍占用执行名额
+func (h *HTTPHandler) limit(ctx context.Context, tool *types.Tool, call func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	timeout := h.toolTimeout(tool)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var acquired []semaphore
+	for _, sem := range []semaphore{h.globalSemaphore(), h.toolSemaphore(tool)} {
+		if sem == nil {
+			continue
+		}
+		if err := sem.acquire(ctx, h.queueTimeout); err != nil {
+			for _, s := range acquired {
+				s.release()
+			}
+			switch {
+			case errors.Is(err, ErrToolBusy):
+				return nil, fmt.Errorf("%w: %s has reached its concurrency limit, try again later", ErrToolBusy, tool.Name)
+			case timeout > 0 && errors.Is(err, context.DeadlineExceeded):
+				// 排队期间耗尽了调用超时
+				return nil, timeoutError(tool, timeout)
+			}
+			return nil, err
+		}
+		acquired = append(acquired, sem)
+	}
+	release := func() {
+		for _, s := range acquired {
+			s.release()
+		}
+	}
+
+	if timeout <= 0 {
+		defer release()
+		return call(ctx)
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		result, err := call(ctx)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, timeoutError(tool, timeout)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, timeoutError(tool, timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// timeoutError 返回工具调用超时的错误
+func timeoutError(tool *types.Tool, timeout time.Duration) error {
+	return fmt.Errorf("%w: %s did not complete within %s", ErrToolTimeout, tool.Name, timeout)
+}
+
+// extendWriteDeadline 按工具的超时调整响应的写超时，避免服务器的写超时在工具返回前断开连接
+// 工具没有超时时取消写超时，客户端断开时通过上下文取消调用
+func (h *HTTPHandler) extendWriteDeadline(w http.ResponseWriter, tool *types.Tool) {
+	deadline := time.Time{}
+	if timeout := h.toolTimeout(tool); timeout > 0 {
+		deadline = time.Now().Add(timeout + writeDeadlineGrace)
+	}
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Printf("Error setting write deadline of tool %s: %v", tool.Name, err)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/types"
+)
+
+// hold 在 h 的并发限制下执行一个阻塞的工具调用，返回结束该调用的函数
+func hold(t *testing.T, h *HTTPHandler, tool *types.Tool) func() {
+	t.Helper()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.limit(context.Background(), tool, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-unblock
+			return nil, nil
+		})
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("blocking call did not start")
+	}
+	return func() {
+		close(unblock)
+		<-done
+	}
+}
+
+// okCall 立即成功的工具调用
+func okCall(ctx context.Context) (interface{}, error) {
+	return "ok", nil
+}
+
+func TestToolTimeout(t *testing.T) {
+	tool := echoTool("slow")
+	tool.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	tool.Timeout = 20 * time.Millisecond
+	mux := newTestMux(t, []types.Tool{tool, echoTool("echo")}, WithDefaultTimeout(time.Second))
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("slow", nil))
+	if isError, _ := resp.Result["isError"].(bool); !isError {
+		t.Fatalf("result = %v, want a tool error", resp.Result)
+	}
+	if got := resultText(t, resp); !strings.Contains(got, "slow did not complete within 20ms") {
+		t.Errorf("error content = %q, want the tool timeout", got)
+	}
+
+	_, resp = postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	if got := resultText(t, resp); got != "hi" {
+		t.Errorf("result = %q, want hi within the default timeout", got)
+	}
+}
+
+func TestToolSemaphore(t *testing.T) {
+	h := NewHTTPHandler(&testServer{}, WithQueueTimeout(20*time.Millisecond))
+	limited := &types.Tool{Name: "limited", MaxConcurrency: 1}
+	other := &types.Tool{Name: "other", MaxConcurrency: 1}
+
+	release := hold(t, h, limited)
+	if _, err := h.limit(context.Background(), limited, okCall); !errors.Is(err, ErrToolBusy) {
+		t.Errorf("limit() of a full tool error = %v, want ErrToolBusy", err)
+	}
+	// 各工具的信号量相互独立
+	if result, err := h.limit(context.Background(), other, okCall); err != nil || result != "ok" {
+		t.Errorf("limit() of another tool = %v, %v, want ok", result, err)
+	}
+	release()
+
+	if result, err := h.limit(context.Background(), limited, okCall); err != nil || result != "ok" {
+		t.Errorf("limit() after release = %v, %v, want ok", result, err)
+	}
+}
+
+func TestGlobalSemaphore(t *testing.T) {
+	h := NewHTTPHandler(&testServer{}, WithMaxConcurrency(1), WithQueueTimeout(20*time.Millisecond))
+	first := &types.Tool{Name: "first"}
+	second := &types.Tool{Name: "second"}
+
+	release := hold(t, h, first)
+	_, err := h.limit(context.Background(), second, okCall)
+	if !errors.Is(err, ErrToolBusy) || !strings.Contains(err.Error(), "second") {
+		t.Errorf("limit() while the server is full error = %v, want ErrToolBusy for second", err)
+	}
+	release()
+
+	if result, err := h.limit(context.Background(), second, okCall); err != nil || result != "ok" {
+		t.Errorf("limit() after release = %v, %v, want ok", result, err)
+	}
+}
+
+func TestQueuedCall(t *testing.T) {
+	t.Run("runs when a slot is released", func(t *testing.T) {
+		h := NewHTTPHandler(&testServer{})
+		tool := &types.Tool{Name: "limited", MaxConcurrency: 1}
+		release := hold(t, h, tool)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := h.limit(context.Background(), tool, okCall)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			t.Fatalf("queued call returned %v before a slot was released", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		release()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("queued call error = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("queued call did not run after release")
+		}
+	})
+
+	t.Run("tool timeout while queued", func(t *testing.T) {
+		h := NewHTTPHandler(&testServer{})
+		tool := &types.Tool{Name: "limited", MaxConcurrency: 1, Timeout: 20 * time.Millisecond}
+		release := hold(t, h, tool)
+		defer release()
+
+		_, err := h.limit(context.Background(), tool, okCall)
+		if !errors.Is(err, ErrToolTimeout) || errors.Is(err, ErrToolBusy) {
+			t.Errorf("limit() error = %v, want ErrToolTimeout", err)
+		}
+	})
+
+	t.Run("queue timeout before tool timeout", func(t *testing.T) {
+		h := NewHTTPHandler(&testServer{}, WithQueueTimeout(10*time.Millisecond))
+		tool := &types.Tool{Name: "limited", MaxConcurrency: 1, Timeout: time.Second}
+		release := hold(t, h, tool)
+		defer release()
+
+		if _, err := h.limit(context.Background(), tool, okCall); !errors.Is(err, ErrToolBusy) {
+			t.Errorf("limit() error = %v, want ErrToolBusy", err)
+		}
+	})
+
+	t.Run("client cancels while queued", func(t *testing.T) {
+		h := NewHTTPHandler(&testServer{})
+		tool := &types.Tool{Name: "limited", MaxConcurrency: 1}
+		release := hold(t, h, tool)
+		defer release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := h.limit(ctx, tool, okCall); !errors.Is(err, context.Canceled) {
+			t.Errorf("limit() error = %v, want context.Canceled", err)
+		}
+	})
+}
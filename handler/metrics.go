@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/types"
+)
+
+// WithMetrics 设置Prometheus指标，记录工具调用、会话和流式调用
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *HTTPHandler) {
+		h.metrics = m
+	}
+}
+
+// WithMetricsRoute 在 RegisterRoutes 中将指标接口挂载到指定路径，如 /metrics
+func WithMetricsRoute(path string) Option {
+	return func(h *HTTPHandler) {
+		h.metricsPath = path
+	}
+}
+
+// observeToolCall 记录工具调用的指标，参数和结果按JSON编码后的大小统计
+func (h *HTTPHandler) observeToolCall(tool *types.Tool, arguments map[string]interface{}, start time.Time, result map[string]interface{}, err error) {
+	if h.metrics == nil {
+		return
+	}
+
+	requestSize := jsonSize(arguments)
+	responseSize := 0
+	if err == nil {
+		responseSize = jsonSize(result)
+	}
+	h.metrics.ObserveToolCall(tool.Name, time.Since(start), requestSize, responseSize, errorType(err))
+}
+
+// errorType 返回指标中的错误类型，调用成功时为空
+func errorType(err error) string {
+	var validationErr *ValidationError
+	var panicErr *PanicError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &validationErr):
+		return "invalid_params"
+	case errors.As(err, &panicErr):
+		return "panic"
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return "unauthenticated"
+	case errors.Is(err, auth.ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrToolTimeout):
+		return "timeout"
+	case errors.Is(err, ErrToolBusy):
+		return "busy"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	default:
+		return "tool_error"
+	}
+}
+
+// jsonSize 返回值按JSON编码后的字节数
+func jsonSize(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
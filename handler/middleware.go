@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+
+	"nacos-mcp-go/types"
+)
+
+// WithMiddleware 添加服务器级工具中间件，先添加的中间件在外层
+func WithMiddleware(middleware ...types.ToolMiddleware) Option {
+	return func(h *HTTPHandler) {
+		h.middleware = append(h.middleware, middleware...)
+	}
+}
+
+// chain 按 服务器级中间件、工具级中间件 的顺序包装工具调用，先添加的中间件在外层
+func (h *HTTPHandler) chain(tool *types.Tool, next types.ToolHandlerFunc) types.ToolHandlerFunc {
+	for i := len(tool.Middleware) - 1; i >= 0; i-- {
+		next = tool.Middleware[i](next)
+	}
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		next = h.middleware[i](next)
+	}
+	return next
+}
+
+// invoke 调用工具函数，流式结果逐个读取后汇总，是中间件链的最内层
+func (h *HTTPHandler) invoke(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+	var result interface{}
+	var err error
+	if req.Tool.Invoke != nil {
+		// 类型安全注册的工具不经过反射
+		result, err = req.Tool.Invoke(ctx, req.Arguments)
+	} else {
+		result, err = h.invokeHandler(ctx, req.Tool, req.Arguments)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sig, ok := streamSignature(req.Tool); ok {
+		return h.drainStream(ctx, req.Tool, sig, reflect.ValueOf(result))
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/types"
+)
+
+// tracingMiddleware 在调用下一环节前后记录 name
+func tracingMiddleware(name string, calls *[]string) types.ToolMiddleware {
+	return func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			*calls = append(*calls, name+">")
+			result, err := next(ctx, req)
+			*calls = append(*calls, "<"+name)
+			return result, err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	tool := echoTool("echo")
+	tool.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		calls = append(calls, "tool")
+		return "ok", nil
+	}
+	tool.Middleware = []types.ToolMiddleware{tracingMiddleware("tool1", &calls), tracingMiddleware("tool2", &calls)}
+	mux := newTestMux(t, []types.Tool{tool},
+		WithMiddleware(tracingMiddleware("server1", &calls)),
+		WithMiddleware(tracingMiddleware("server2", &calls)),
+	)
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("echo", nil))
+	if got := resultText(t, resp); got != "ok" {
+		t.Fatalf("result = %q, want ok", got)
+	}
+	// 服务器级中间件在工具级中间件之外，同级先添加的在外层
+	want := []string{"server1>", "server2>", "tool1>", "tool2>", "tool", "<tool2", "<tool1", "<server2", "<server1"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareModifiesArguments(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *types.ToolRequest)
+		want   string
+	}{
+		{
+			name:   "set argument",
+			modify: func(req *types.ToolRequest) { req.Arguments["message"] = "rewritten" },
+			want:   "rewritten",
+		},
+		{
+			name: "replace arguments",
+			modify: func(req *types.ToolRequest) {
+				req.Arguments = map[string]interface{}{"message": strings.ToUpper(req.Arguments["message"].(string))}
+			},
+			want: "HI",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewrite := func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+				return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+					tt.modify(req)
+					return next(ctx, req)
+				}
+			}
+			// 工具级中间件看到服务器级中间件修改后的参数
+			var seen interface{}
+			tool := echoTool("echo")
+			tool.Middleware = []types.ToolMiddleware{func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+				return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+					seen = req.Arguments["message"]
+					return next(ctx, req)
+				}
+			}}
+			mux := newTestMux(t, []types.Tool{tool}, WithMiddleware(rewrite))
+
+			_, resp := postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+			if got := resultText(t, resp); got != tt.want {
+				t.Errorf("result = %q, want %q", got, tt.want)
+			}
+			if seen != tt.want {
+				t.Errorf("tool middleware saw %v, want %q", seen, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	called := false
+	tool := echoTool("echo")
+	tool.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	deny := func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			if req.Tool.Name == "echo" && req.Arguments["message"] == "blocked" {
+				return nil, errors.New("message rejected by policy")
+			}
+			return "cached", nil
+		}
+	}
+	mux := newTestMux(t, []types.Tool{tool}, WithMiddleware(deny))
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	if got := resultText(t, resp); got != "cached" {
+		t.Errorf("result = %q, want the middleware result", got)
+	}
+
+	_, resp = postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "blocked"}))
+	if isError, _ := resp.Result["isError"].(bool); !isError {
+		t.Fatalf("result = %v, want a tool error", resp.Result)
+	}
+	if got := resultText(t, resp); !strings.Contains(got, "rejected by policy") {
+		t.Errorf("error content = %q, want the middleware error", got)
+	}
+	if called {
+		t.Error("tool was called although the middleware did not call next")
+	}
+}
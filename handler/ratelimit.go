@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nacos-mcp-go/ratelimit"
+	"nacos-mcp-go/types"
+)
+
+// CodeRateLimited 调用超出限流时返回的JSON-RPC错误码
+const CodeRateLimited = -32029
+
+// ErrRateLimited 调用超出限流
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// WithRateLimitStore 设置保存令牌桶状态的存储，未设置时使用进程内存储
+func WithRateLimitStore(store ratelimit.Store) Option {
+	return func(h *HTTPHandler) {
+		h.rateLimitStore = store
+	}
+}
+
+// WithPrincipalRateLimit 为每个已认证的调用方设置跨所有工具的限流
+func WithPrincipalRateLimit(limit types.RateLimit) Option {
+	return func(h *HTTPHandler) {
+		h.principalLimit = limit
+	}
+}
+
+// WithSessionRateLimit 为每个会话设置跨所有工具的限流，没有有效会话的请求按调用方或客户端地址限流
+func WithSessionRateLimit(limit types.RateLimit) Option {
+	return func(h *HTTPHandler) {
+		h.sessionLimit = limit
+	}
+}
+
+// RateLimitError 调用超出限流
+type RateLimitError struct {
+	Tool       string
+	Scope      string        // 触发限流的维度：tool、principal 或 session
+	RetryAfter time.Duration // 再次调用前需要等待的时长
+}
+
+// Error 实现error接口
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v: %s limit for tool %s, retry after %s", ErrRateLimited, e.Scope, e.Tool, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Unwrap 使 errors.Is(err, ErrRateLimited) 成立
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// retryAfterSeconds 返回 Retry-After 的秒数，向上取整且至少为1
+func (e *RateLimitError) retryAfterSeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// rateLimitRule 调用需要检查的一个令牌桶
+type rateLimitRule struct {
+	scope string
+	key   string
+	limit types.RateLimit
+}
+
+// rateLimitRules 返回调用适用的令牌桶：调用方的、会话的和工具共享的
+// 各调用方自己的令牌桶排在前面，被限流的调用方不会消耗其他调用方共享的工具配额
+// 键带有服务器名，多个服务器共用存储时互不影响
+func (h *HTTPHandler) rateLimitRules(ctx context.Context, tool *types.Tool) []rateLimitRule {
+	h.mu.RLock()
+	prefix := h.server.GetName() + "/"
+	h.mu.RUnlock()
+
+	var rules []rateLimitRule
+	if h.principalLimit.Rate > 0 {
+		// 没有标识的调用方无法区分，不按调用方限流
+		if principal, ok := principalKey(ctx); ok {
+			rules = append(rules, rateLimitRule{"principal", prefix + "principal/" + principal, h.principalLimit})
+		}
+	}
+	if h.sessionLimit.Rate > 0 {
+		if client := sessionClient(ctx); client != "" {
+			rules = append(rules, rateLimitRule{"session", prefix + "session/" + client, h.sessionLimit})
+		}
+	}
+	if tool.RateLimit != nil && tool.RateLimit.Rate > 0 {
+		rules = append(rules, rateLimitRule{"tool", prefix + "tool/" + tool.Name, *tool.RateLimit})
+	}
+	return rules
+}
+
+// sessionClient 返回会话限流的键：服务端签发的会话，没有有效会话时依次退回到已认证调用方和客户端地址
+// 不带会话ID或带伪造会话ID的请求因此无法绕过会话限流
+func sessionClient(ctx context.Context) string {
+	if session := sessionFromContext(ctx); session != "" {
+		return "id/" + session
+	}
+	if principal, ok := principalKey(ctx); ok {
+		return "principal/" + principal
+	}
+	if addr := remoteAddrFromContext(ctx); addr != "" {
+		return "addr/" + addr
+	}
+	return ""
+}
+
+// rateLimit 依次从适用的令牌桶取令牌，任一令牌桶不足时返回 RateLimitError
+// 存储实现 ratelimit.Refunder 时，被拒绝的调用退还已从前面的令牌桶取出的令牌
+// 存储出错时记录日志并放行，避免共享存储不可用导致所有调用失败
+func (h *HTTPHandler) rateLimit(ctx context.Context, tool *types.Tool) error {
+	var taken []rateLimitRule
+	for _, rule := range h.rateLimitRules(ctx, tool) {
+		allowed, retryAfter, err := h.rateLimitStore.Allow(ctx, rule.key, rule.limit)
+		if err != nil {
+			h.logger.Printf("Error checking %s rate limit of tool %s: %v", rule.scope, tool.Name, err)
+			continue
+		}
+		if !allowed {
+			h.refundRateLimit(ctx, tool, taken)
+			return &RateLimitError{Tool: tool.Name, Scope: rule.scope, RetryAfter: retryAfter}
+		}
+		taken = append(taken, rule)
+	}
+	return nil
+}
+
+// refundRateLimit 向存储退还从 rules 中取出的令牌，存储不支持退还时不做处理
+func (h *HTTPHandler) refundRateLimit(ctx context.Context, tool *types.Tool, rules []rateLimitRule) {
+	refunder, ok := h.rateLimitStore.(ratelimit.Refunder)
+	if !ok {
+		return
+	}
+	for _, rule := range rules {
+		if err := refunder.Refund(ctx, rule.key, rule.limit); err != nil {
+			h.logger.Printf("Error refunding %s rate limit of tool %s: %v", rule.scope, tool.Name, err)
+		}
+	}
+}
+
+// rateLimitedError 将限流错误转换为JSON-RPC错误，data 中的 retryAfter 为秒数
+func rateLimitedError(err *RateLimitError) *RPCError {
+	return &RPCError{
+		Code:    CodeRateLimited,
+		Message: err.Error(),
+		Data: map[string]interface{}{
+			"retryAfter": err.retryAfterSeconds(),
+			"scope":      err.Scope,
+		},
+	}
+}
+
+// retryAfter 读取限流错误中的等待秒数
+func retryAfter(rpcErr *RPCError) int {
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok {
+		return 1
+	}
+	seconds, ok := data["retryAfter"].(int)
+	if !ok {
+		return 1
+	}
+	return seconds
+}
+
+// writeRateLimited 写入限流时的429状态和 Retry-After 响应头，响应体由调用方写入
+func (h *HTTPHandler) writeRateLimited(w http.ResponseWriter, retryAfter int) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+}
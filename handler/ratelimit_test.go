@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/auth"
+	"nacos-mcp-go/ratelimit"
+	"nacos-mcp-go/types"
+)
+
+// callEcho 调用 echo 工具，返回HTTP状态码和JSON-RPC错误码
+func callEcho(t *testing.T, handler http.Handler, header http.Header) (int, int) {
+	t.Helper()
+	w, resp := postRPC(t, handler, header, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	if resp.Error != nil {
+		return w.Code, resp.Error.Code
+	}
+	return w.Code, 0
+}
+
+func TestToolRateLimit(t *testing.T) {
+	tool := echoTool("echo")
+	tool.RateLimit = &types.RateLimit{Rate: 0.01, Burst: 2}
+	mux := newTestMux(t, []types.Tool{tool})
+
+	for i := 0; i < 2; i++ {
+		if status, code := callEcho(t, mux, nil); status != http.StatusOK || code != 0 {
+			t.Fatalf("call %d: status = %d, code = %d, want success within the burst", i, status, code)
+		}
+	}
+
+	w, resp := postRPC(t, mux, nil, "tools/call", callParams("echo", nil))
+	if w.Code != http.StatusTooManyRequests || resp.Error == nil || resp.Error.Code != CodeRateLimited {
+		t.Fatalf("status = %d, error = %+v, want 429 with -32029", w.Code, resp.Error)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("rate limited response has no Retry-After header")
+	}
+	if scope := resp.Error.Data["scope"]; scope != "tool" {
+		t.Errorf("scope = %v, want tool", scope)
+	}
+}
+
+func TestSessionRateLimitPerIssuedSession(t *testing.T) {
+	mux := newTestMux(t, []types.Tool{echoTool("echo")}, WithSessionRateLimit(ratelimit.PerMinute(1)))
+
+	var sessions []string
+	for i := 0; i < 2; i++ {
+		w, _ := postRPC(t, mux, nil, "initialize", nil)
+		sessions = append(sessions, w.Header().Get(SessionHeader))
+	}
+
+	for _, session := range sessions {
+		if _, code := callEcho(t, mux, sessionHeader(session)); code != 0 {
+			t.Fatalf("first call of session %s: code = %d, want success", session, code)
+		}
+	}
+	if _, code := callEcho(t, mux, sessionHeader(sessions[0])); code != CodeRateLimited {
+		t.Errorf("second call of a session: code = %d, want %d", code, CodeRateLimited)
+	}
+}
+
+func TestSessionRateLimitCannotBeAvoidedWithoutIssuedSession(t *testing.T) {
+	mux := newTestMux(t, []types.Tool{echoTool("echo")}, WithSessionRateLimit(ratelimit.PerMinute(1)))
+
+	if _, code := callEcho(t, mux, nil); code != 0 {
+		t.Fatalf("first anonymous call: code = %d, want success", code)
+	}
+	for i := 0; i < 3; i++ {
+		if _, code := callEcho(t, mux, sessionHeader(fmt.Sprintf("made-up-%d", i))); code != CodeRateLimited {
+			t.Errorf("call with made-up session %d: code = %d, want %d from the client address limit", i, code, CodeRateLimited)
+		}
+	}
+	if _, code := callEcho(t, mux, nil); code != CodeRateLimited {
+		t.Errorf("call without session: code = %d, want %d", code, CodeRateLimited)
+	}
+}
+
+func TestSessionRateLimitFallsBackToPrincipal(t *testing.T) {
+	mux := newTestMux(t, []types.Tool{echoTool("echo")},
+		WithSessionRateLimit(ratelimit.PerMinute(1)),
+		WithAuthenticators(auth.NewAPIKeyAuthenticator(map[string]*auth.Principal{
+			"k-alice": {Subject: "alice"},
+			"k-bob":   {Subject: "bob"},
+		})),
+	)
+
+	for _, key := range []string{"k-alice", "k-bob"} {
+		if _, code := callEcho(t, mux, apiKeyHeader(key)); code != 0 {
+			t.Fatalf("first call of %s: code = %d, want success", key, code)
+		}
+	}
+
+	header := apiKeyHeader("k-alice")
+	header.Set(SessionHeader, "made-up")
+	if _, code := callEcho(t, mux, header); code != CodeRateLimited {
+		t.Errorf("second call of alice with a made-up session: code = %d, want %d", code, CodeRateLimited)
+	}
+}
+
+// principalMux 创建按调用方限流、echo 工具带有 toolLimit 的处理器路由，API Key 对应 principals 中的调用方
+func principalMux(t *testing.T, toolLimit types.RateLimit, principals map[string]*auth.Principal) *http.ServeMux {
+	t.Helper()
+	tool := echoTool("echo")
+	tool.RateLimit = &toolLimit
+	return newTestMux(t, []types.Tool{tool},
+		WithPrincipalRateLimit(ratelimit.PerMinute(1)),
+		WithAuthenticators(auth.NewAPIKeyAuthenticator(principals)),
+	)
+}
+
+func TestPrincipalRateLimitDoesNotConsumeToolLimit(t *testing.T) {
+	mux := principalMux(t, types.RateLimit{Rate: 0.01, Burst: 2}, map[string]*auth.Principal{
+		"k-alice": {Subject: "alice"},
+		"k-bob":   {Subject: "bob"},
+	})
+
+	if _, code := callEcho(t, mux, apiKeyHeader("k-alice")); code != 0 {
+		t.Fatalf("first call of alice: code = %d, want success", code)
+	}
+	for i := 0; i < 5; i++ {
+		w, resp := postRPC(t, mux, apiKeyHeader("k-alice"), "tools/call", callParams("echo", nil))
+		if resp.Error == nil || resp.Error.Data["scope"] != "principal" {
+			t.Fatalf("call %d of alice: status = %d, error = %+v, want the principal limit", i, w.Code, resp.Error)
+		}
+	}
+	// 被限流的调用没有消耗工具共享的配额
+	if _, code := callEcho(t, mux, apiKeyHeader("k-bob")); code != 0 {
+		t.Errorf("first call of bob: code = %d, want success", code)
+	}
+}
+
+func TestRateLimitRejectionRefundsEarlierBuckets(t *testing.T) {
+	mux := principalMux(t, types.RateLimit{Rate: 50, Burst: 1}, map[string]*auth.Principal{
+		"k-alice": {Subject: "alice"},
+		"k-bob":   {Subject: "bob"},
+	})
+
+	if _, code := callEcho(t, mux, apiKeyHeader("k-alice")); code != 0 {
+		t.Fatalf("call of alice: code = %d, want success", code)
+	}
+	w, resp := postRPC(t, mux, apiKeyHeader("k-bob"), "tools/call", callParams("echo", nil))
+	if resp.Error == nil || resp.Error.Data["scope"] != "tool" {
+		t.Fatalf("call of bob: status = %d, error = %+v, want the tool limit", w.Code, resp.Error)
+	}
+	// 工具配额补充后，bob 的调用方配额仍然可用
+	time.Sleep(50 * time.Millisecond)
+	if _, code := callEcho(t, mux, apiKeyHeader("k-bob")); code != 0 {
+		t.Errorf("call of bob after the tool refilled: code = %d, want success", code)
+	}
+}
+
+func TestPrincipalRateLimitKey(t *testing.T) {
+	mux := principalMux(t, types.RateLimit{}, map[string]*auth.Principal{
+		"k-a":       {Subject: "alice", Claims: map[string]interface{}{"iss": "https://a.example.com"}},
+		"k-b":       {Subject: "alice", Claims: map[string]interface{}{"iss": "https://b.example.com"}},
+		"k-service": {},
+	})
+
+	// 不同签发方中的同名调用方各有令牌桶
+	for _, key := range []string{"k-a", "k-b"} {
+		if _, code := callEcho(t, mux, apiKeyHeader(key)); code != 0 {
+			t.Errorf("first call with %s: code = %d, want success", key, code)
+		}
+	}
+	if _, code := callEcho(t, mux, apiKeyHeader("k-a")); code != CodeRateLimited {
+		t.Errorf("second call with k-a: code = %d, want %d", code, CodeRateLimited)
+	}
+
+	// 没有标识的调用方不按调用方限流，避免所有这类调用方共用一个令牌桶
+	for i := 0; i < 3; i++ {
+		if _, code := callEcho(t, mux, apiKeyHeader("k-service")); code != 0 {
+			t.Errorf("call %d of a principal without subject: code = %d, want success", i, code)
+		}
+	}
+}
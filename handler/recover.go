@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/types"
+)
+
+// CodeToolPanic 工具函数发生panic时返回的JSON-RPC错误码，即 Internal error
+const CodeToolPanic = CodeInternalError
+
+// WithLogger 设置处理器的日志输出，工具panic的堆栈也写入该日志，未设置时使用 log.Default()
+func WithLogger(logger *log.Logger) Option {
+	return func(h *HTTPHandler) {
+		if logger != nil {
+			h.logger = logger
+		}
+	}
+}
+
+// PanicError 工具函数、中间件或格式化函数发生panic
+// 返回给客户端的错误只包含关联ID，panic的值和堆栈只写入日志
+type PanicError struct {
+	Tool          string
+	CorrelationID string      // 关联客户端错误和服务端日志的ID
+	Value         interface{} // panic的值
+}
+
+// Error 实现error接口
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("internal error in tool %s (correlation id: %s)", e.Tool, e.CorrelationID)
+}
+
+// recoverPanic 调用 call 并将其中的panic转换为 PanicError，记录堆栈和panic指标
+func (h *HTTPHandler) recoverPanic(ctx context.Context, tool *types.Tool, call func() (interface{}, error)) (result interface{}, err error) {
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+
+		panicErr := &PanicError{Tool: tool.Name, CorrelationID: correlationID(), Value: value}
+		traceID := ""
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			traceID = " trace_id=" + sc.TraceID().String()
+		}
+		h.logger.Printf("Panic in tool %s: correlation_id=%s%s: %v\n%s", tool.Name, panicErr.CorrelationID, traceID, value, debug.Stack())
+		h.metrics.ObservePanic(tool.Name)
+
+		result, err = nil, panicErr
+	}()
+	return call()
+}
+
+// panicError 将 PanicError 转换为JSON-RPC内部错误，data 中带有关联ID
+func panicError(err *PanicError) *RPCError {
+	return &RPCError{
+		Code:    CodeToolPanic,
+		Message: "Internal error",
+		Data: map[string]interface{}{
+			"tool":          err.Tool,
+			"correlationId": err.CorrelationID,
+		},
+	}
+}
+
+// correlationID 生成随机的关联ID
+func correlationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/types"
+)
+
+// panicTool 调用时panic的工具
+func panicTool(name string) types.Tool {
+	tool := echoTool(name)
+	tool.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		panic("secret connection string")
+	}
+	return tool
+}
+
+// toolPanics 读取注册表中工具 tool 的 mcp_tool_panics_total
+func toolPanics(t *testing.T, reg *prometheus.Registry, tool string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "mcp_tool_panics_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "tool" && label.GetValue() == tool {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestToolPanic(t *testing.T) {
+	var logs bytes.Buffer
+	reg := prometheus.NewRegistry()
+	m, err := metrics.New(metrics.WithRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(t, []types.Tool{panicTool("explode"), echoTool("echo")},
+		WithLogger(log.New(&logs, "", 0)),
+		WithMetrics(m),
+	)
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("explode", nil))
+	if resp.Error == nil || resp.Error.Code != CodeToolPanic || resp.Error.Message != "Internal error" {
+		t.Fatalf("error = %+v, want an internal error", resp.Error)
+	}
+	id, _ := resp.Error.Data["correlationId"].(string)
+	if id == "" || resp.Error.Data["tool"] != "explode" {
+		t.Fatalf("error data = %v, want the tool and a correlation ID", resp.Error.Data)
+	}
+	if body, _ := json.Marshal(resp.Error); strings.Contains(string(body), "secret") {
+		t.Errorf("error %s exposes the panic value", body)
+	}
+
+	// 日志带有相同的关联ID、panic的值和堆栈
+	logged := logs.String()
+	for _, want := range []string{"Panic in tool explode: correlation_id=" + id, "secret connection string", "goroutine ", "recover_test.go"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log does not contain %q:\n%s", want, logged)
+		}
+	}
+	if got := toolPanics(t, reg, "explode"); got != 1 {
+		t.Errorf("mcp_tool_panics_total = %v, want 1", got)
+	}
+
+	// 服务器在panic后继续处理请求
+	_, resp = postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	if got := resultText(t, resp); got != "hi" {
+		t.Errorf("result after a panic = %q, want hi", got)
+	}
+
+	_, second := postRPC(t, mux, nil, "tools/call", callParams("explode", nil))
+	if second.Error == nil || second.Error.Data["correlationId"] == id {
+		t.Errorf("second panic error = %+v, want a new correlation ID", second.Error)
+	}
+	if got := toolPanics(t, reg, "explode"); got != 2 {
+		t.Errorf("mcp_tool_panics_total = %v, want 2", got)
+	}
+}
+
+func TestToolPanicInvokeRoute(t *testing.T) {
+	var logs bytes.Buffer
+	mux := newTestMux(t, []types.Tool{panicTool("explode")}, WithLogger(log.New(&logs, "", 0)))
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp/tools/explode/invoke", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var body struct {
+		Error struct {
+			Code int                    `json:"code"`
+			Data map[string]interface{} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	id, _ := body.Error.Data["correlationId"].(string)
+	if body.Error.Code != CodeToolPanic || id == "" {
+		t.Fatalf("error = %+v, want an internal error with a correlation ID", body.Error)
+	}
+	if !strings.Contains(logs.String(), "correlation_id="+id) {
+		t.Errorf("log does not contain correlation ID %s:\n%s", id, logs.String())
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionHeader 会话ID的请求头和响应头，服务端在 initialize 响应中签发
+const SessionHeader = "Mcp-Session-Id"
+
+const (
+	// DefaultSessionTimeout 会话在没有请求后保留的默认时长
+	DefaultSessionTimeout = 30 * time.Minute
+	// DefaultMaxSessions 默认同时保留的最大会话数
+	DefaultMaxSessions = 10000
+)
+
+// sessionKey 上下文中会话ID的键
+type sessionKey struct{}
+
+// remoteAddrKey 上下文中客户端地址的键
+type remoteAddrKey struct{}
+
+// WithSessionTimeout 设置会话在没有请求后保留的时长，超时的会话ID不再被接受
+func WithSessionTimeout(timeout time.Duration) Option {
+	return func(h *HTTPHandler) {
+		h.sessionTimeout = timeout
+	}
+}
+
+// WithMaxSessions 设置同时保留的最大会话数，超出时淘汰最久没有请求的会话
+func WithMaxSessions(n int) Option {
+	return func(h *HTTPHandler) {
+		h.maxSessions = n
+	}
+}
+
+// sessionStore 服务端签发的会话，按最近一次请求的时间过期
+type sessionStore struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	size     int
+	lastSeen map[string]time.Time
+	now      func() time.Time
+}
+
+// newSessionStore 创建最多保留 size 个会话的存储
+func newSessionStore(timeout time.Duration, size int) *sessionStore {
+	if timeout <= 0 {
+		timeout = DefaultSessionTimeout
+	}
+	if size <= 0 {
+		size = DefaultMaxSessions
+	}
+	return &sessionStore{
+		timeout:  timeout,
+		size:     size,
+		lastSeen: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// issue 签发新的会话ID，会话数已满时先清理超时的会话，仍然已满则淘汰最久没有请求的会话
+func (s *sessionStore) issue() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.lastSeen) >= s.size {
+		s.prune(now)
+	}
+	for len(s.lastSeen) >= s.size {
+		s.evictOldest()
+	}
+
+	session := rand.Text()
+	s.lastSeen[session] = now
+	return session
+}
+
+// touch 记录会话的一次请求，会话未签发或已超时时返回 false
+func (s *sessionStore) touch(session string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, ok := s.lastSeen[session]
+	if !ok {
+		return false
+	}
+	now := s.now()
+	if now.Sub(seen) > s.timeout {
+		delete(s.lastSeen, session)
+		return false
+	}
+	s.lastSeen[session] = now
+	return true
+}
+
+// prune 删除超时的会话
+func (s *sessionStore) prune(now time.Time) {
+	for session, seen := range s.lastSeen {
+		if now.Sub(seen) > s.timeout {
+			delete(s.lastSeen, session)
+		}
+	}
+}
+
+// evictOldest 删除最久没有请求的会话
+func (s *sessionStore) evictOldest() {
+	var oldest string
+	var oldestSeen time.Time
+	for session, seen := range s.lastSeen {
+		if oldest == "" || seen.Before(oldestSeen) {
+			oldest, oldestSeen = session, seen
+		}
+	}
+	delete(s.lastSeen, oldest)
+}
+
+// issueSession 签发会话ID并写入响应头
+func (h *HTTPHandler) issueSession(w http.ResponseWriter) {
+	w.Header().Set(SessionHeader, h.sessions.issue())
+}
+
+// bindSession 将客户端地址和请求携带的会话ID写入请求上下文
+// 只有服务端签发且未超时的会话被写入并计入指标，未签发或已超时的会话ID被忽略，请求按没有会话处理
+func (h *HTTPHandler) bindSession(r *http.Request) *http.Request {
+	ctx := context.WithValue(r.Context(), remoteAddrKey{}, remoteHost(r))
+	if session := r.Header.Get(SessionHeader); session != "" && h.sessions.touch(session) {
+		h.metrics.TouchSession(session)
+		ctx = context.WithValue(ctx, sessionKey{}, session)
+	}
+	return r.WithContext(ctx)
+}
+
+// remoteHost 返回请求的来源地址，不含端口
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// sessionFromContext 读取上下文中的会话ID
+func sessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(sessionKey{}).(string)
+	return session
+}
+
+// remoteAddrFromContext 读取上下文中的客户端地址
+func remoteAddrFromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(remoteAddrKey{}).(string)
+	return addr
+}
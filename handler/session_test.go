@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/types"
+)
+
+// sessionRecorder 记录工具调用收到的会话ID的中间件
+func sessionRecorder(sessions *[]string) types.ToolMiddleware {
+	return func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			*sessions = append(*sessions, req.Session)
+			return next(ctx, req)
+		}
+	}
+}
+
+// sessionHeader 返回携带会话ID的请求头
+func sessionHeader(session string) http.Header {
+	return http.Header{SessionHeader: {session}}
+}
+
+func TestInitializeIssuesSession(t *testing.T) {
+	mux := newTestMux(t, nil)
+
+	w, resp := postRPC(t, mux, nil, "initialize", map[string]interface{}{"protocolVersion": LatestProtocolVersion})
+	if resp.Error != nil {
+		t.Fatalf("initialize error = %+v", resp.Error)
+	}
+	first := w.Header().Get(SessionHeader)
+	if first == "" {
+		t.Fatal("initialize response has no Mcp-Session-Id header")
+	}
+
+	w, _ = postRPC(t, mux, nil, "initialize", nil)
+	if second := w.Header().Get(SessionHeader); second == "" || second == first {
+		t.Errorf("second session = %q, want a new ID distinct from %q", second, first)
+	}
+
+	w, _ = postRPC(t, mux, sessionHeader(first), "tools/list", nil)
+	if got := w.Header().Get(SessionHeader); got != "" {
+		t.Errorf("tools/list issued session %q, want sessions issued only by initialize", got)
+	}
+}
+
+func TestToolRequestOnlyCarriesIssuedSessions(t *testing.T) {
+	var sessions []string
+	mux := newTestMux(t, []types.Tool{echoTool("echo")}, WithMiddleware(sessionRecorder(&sessions)))
+
+	w, _ := postRPC(t, mux, nil, "initialize", nil)
+	issued := w.Header().Get(SessionHeader)
+
+	for _, header := range []http.Header{sessionHeader(issued), sessionHeader("client-chosen"), nil} {
+		if _, resp := postRPC(t, mux, header, "tools/call", callParams("echo", nil)); resp.Error != nil {
+			t.Fatalf("tools/call error = %+v", resp.Error)
+		}
+	}
+
+	want := []string{issued, "", ""}
+	if len(sessions) != len(want) {
+		t.Fatalf("sessions = %q, want %q", sessions, want)
+	}
+	for i := range want {
+		if sessions[i] != want[i] {
+			t.Errorf("call %d session = %q, want %q", i, sessions[i], want[i])
+		}
+	}
+}
+
+func TestActiveSessionsCountOnlyIssuedSessions(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := metrics.New(metrics.WithRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := newTestMux(t, nil, WithMetrics(m))
+
+	for i := 0; i < 3; i++ {
+		postRPC(t, mux, sessionHeader(fmt.Sprintf("forged-%d", i)), "ping", nil)
+	}
+	if got := activeSessions(t, reg); got != 0 {
+		t.Errorf("active sessions after forged IDs = %v, want 0", got)
+	}
+
+	w, _ := postRPC(t, mux, nil, "initialize", nil)
+	postRPC(t, mux, sessionHeader(w.Header().Get(SessionHeader)), "ping", nil)
+	if got := activeSessions(t, reg); got != 1 {
+		t.Errorf("active sessions after an issued ID = %v, want 1", got)
+	}
+}
+
+// activeSessions 读取注册表中 mcp_active_sessions 的值
+func activeSessions(t *testing.T, reg *prometheus.Registry) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "mcp_active_sessions" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatal("mcp_active_sessions is not registered")
+	return 0
+}
+
+func TestSessionStoreExpiresIdleSessions(t *testing.T) {
+	now := time.Now()
+	store := newSessionStore(time.Minute, 10)
+	store.now = func() time.Time { return now }
+
+	session := store.issue()
+	now = now.Add(50 * time.Second)
+	if !store.touch(session) {
+		t.Fatal("touch() = false within the timeout")
+	}
+	now = now.Add(50 * time.Second)
+	if !store.touch(session) {
+		t.Fatal("touch() = false, want the previous request to extend the session")
+	}
+	now = now.Add(2 * time.Minute)
+	if store.touch(session) {
+		t.Error("touch() = true after the session timed out")
+	}
+	if len(store.lastSeen) != 0 {
+		t.Errorf("store keeps %d sessions after expiry, want 0", len(store.lastSeen))
+	}
+}
+
+func TestSessionStoreEvictsLeastRecentlySeen(t *testing.T) {
+	now := time.Now()
+	store := newSessionStore(time.Hour, 2)
+	store.now = func() time.Time { return now }
+
+	first := store.issue()
+	now = now.Add(time.Second)
+	second := store.issue()
+	now = now.Add(time.Second)
+	store.touch(first)
+	now = now.Add(time.Second)
+	third := store.issue()
+
+	if len(store.lastSeen) != 2 {
+		t.Fatalf("store keeps %d sessions, want 2", len(store.lastSeen))
+	}
+	if store.touch(second) {
+		t.Error("least recently seen session was not evicted")
+	}
+	if !store.touch(first) || !store.touch(third) {
+		t.Error("recently seen sessions were evicted")
+	}
+}
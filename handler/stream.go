@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/types"
+)
+
+// progressKey 上下文中进度回调的键
+type progressKey struct{}
+
+// progressFunc 流式工具每产生一个元素时的回调
+type progressFunc func(item interface{})
+
+// withProgress 在上下文中设置进度回调
+func withProgress(ctx context.Context, fn progressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// notifyProgress 调用上下文中的进度回调，未设置时忽略
+func notifyProgress(ctx context.Context, item interface{}) {
+	if fn, ok := ctx.Value(progressKey{}).(progressFunc); ok {
+		fn(item)
+	}
+}
+
+// drainStream 读取流式返回值的全部元素并汇总为切片
+// 每个元素在进度回调返回后才读取下一个，客户端写入缓慢时生产者随之阻塞；上下文取消时立即停止读取
+func (h *HTTPHandler) drainStream(ctx context.Context, tool *types.Tool, sig *scanner.Signature, stream reflect.Value) (interface{}, error) {
+	items := reflect.MakeSlice(reflect.SliceOf(sig.Elem), 0, 0)
+	emit := func(item reflect.Value) {
+		items = reflect.Append(items, item)
+		notifyProgress(ctx, item.Interface())
+	}
+
+	if !stream.IsValid() || stream.IsNil() {
+		return items.Interface(), nil
+	}
+
+	switch sig.Stream {
+	case scanner.StreamChan:
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: stream},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 1 {
+				return nil, ctx.Err()
+			}
+			if !ok {
+				break
+			}
+			emit(item)
+		}
+
+	case scanner.StreamSeq, scanner.StreamSeq2:
+		var streamErr error
+		yieldType := stream.Type().In(0)
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			if ctx.Err() != nil {
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			if sig.Stream == scanner.StreamSeq2 && !args[1].IsNil() {
+				streamErr = args[1].Interface().(error)
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			emit(args[0])
+			return []reflect.Value{reflect.ValueOf(ctx.Err() == nil)}
+		})
+		stream.Call([]reflect.Value{yield})
+
+		if streamErr != nil {
+			return nil, streamErr
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return items.Interface(), nil
+}
+
+// streamSignature 获取返回流式结果的工具函数签名
+func streamSignature(tool *types.Tool) (*scanner.Signature, bool) {
+	sig, err := scanner.ParseSignature(reflect.TypeOf(tool.Handler))
+	if err != nil || sig.Stream == scanner.StreamNone {
+		return nil, false
+	}
+	return sig, true
+}
+
+// isStreamingTool 判断工具是否返回流式结果
+func (h *HTTPHandler) isStreamingTool(name string) (*types.Tool, bool) {
+	tool, ok := h.findTool(name)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := streamSignature(tool); !ok {
+		return nil, false
+	}
+	return tool, true
+}
+
+// acceptsEventStream 判断客户端是否接受SSE响应
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// callProgressToken 读取 tools/call 请求的工具名以及 _meta 中的 progressToken
+func callProgressToken(params json.RawMessage) (string, json.RawMessage, bool) {
+	var call struct {
+		Name string `json:"name"`
+		Meta struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return "", nil, false
+	}
+	token := call.Meta.ProgressToken
+	if len(token) == 0 || string(token) == "null" {
+		return "", nil, false
+	}
+	return call.Name, token, true
+}
+
+// sseWriter 以SSE事件写入JSON-RPC消息
+type sseWriter struct {
+	mu         sync.Mutex
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	closed     bool
+}
+
+// errStreamClosed 响应已结束，超时后仍在执行的工具不能再写入
+var errStreamClosed = errors.New("stream closed")
+
+// write 写入一条消息事件并立即刷新
+func (s *sseWriter) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errStreamClosed
+	}
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+// close 结束写入，之后的写入返回 errStreamClosed
+func (s *sseWriter) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+}
+
+// serveStreamingCall 以SSE响应流式工具调用
+// 每个元素作为 notifications/progress 通知发送，最后发送汇总后的调用结果，返回调用的JSON-RPC错误
+func (h *HTTPHandler) serveStreamingCall(ctx context.Context, w http.ResponseWriter, req *jsonrpcRequest, tool *types.Tool, token json.RawMessage) *RPCError {
+	controller := http.NewResponseController(w)
+	if _, ok := w.(http.Flusher); !ok {
+		result, rpcErr := h.dispatch(ctx, req)
+		h.writeRPC(w, req.ID, result, rpcErr)
+		return rpcErr
+	}
+
+	// 流式响应的时长取决于工具，取消服务器的写超时，客户端断开时通过上下文取消
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Printf("Error clearing write deadline of tool %s: %v", tool.Name, err)
+	}
+
+	done := h.metrics.StreamStarted()
+	defer done()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream := &sseWriter{w: w, controller: controller}
+	defer stream.close()
+	progress := 0
+	ctx = withProgress(ctx, func(item interface{}) {
+		progress++
+		params := map[string]interface{}{
+			"progressToken": token,
+			"progress":      progress,
+		}
+		if text, err := h.formatItem(tool, item); err == nil {
+			params["message"] = text
+		}
+		notification := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "notifications/progress",
+			"params":  params,
+		}
+		if err := stream.write(notification); err != nil && !errors.Is(err, errStreamClosed) {
+			// 客户端已断开，取消调用
+			h.logger.Printf("Error writing progress of tool %s: %v", tool.Name, err)
+			cancel()
+		}
+	})
+
+	result, rpcErr := h.dispatch(ctx, req)
+	if err := stream.write(jsonrpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+		h.logger.Printf("Error writing response of tool %s: %v", tool.Name, err)
+	}
+	return rpcErr
+}
+
+// formatItem 使用工具的格式化函数格式化流式结果中的单个元素
+func (h *HTTPHandler) formatItem(tool *types.Tool, item interface{}) (string, error) {
+	formatter, err := h.formatter(tool)
+	if err != nil {
+		return "", err
+	}
+	return formatter(item)
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/types"
+)
+
+// countSchema 以参数 n 指定元素个数的输入schema
+var countSchema = map[string]interface{}{
+	"type":       "object",
+	"properties": map[string]interface{}{"n": map[string]interface{}{"type": "integer"}},
+}
+
+// streamTool 以 handler 为处理函数、参数为 n 的流式工具
+func streamTool(name string, handler interface{}) types.Tool {
+	return types.Tool{Name: name, InputSchema: countSchema, Handler: handler, ParamNames: []string{"n"}}
+}
+
+// countChan 通过通道依次返回 1..n
+func countChan(ctx context.Context, n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= n; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// countSeq 通过 iter.Seq 依次返回 "1".."n"
+func countSeq(n int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(strconv.Itoa(i)) {
+				return
+			}
+		}
+	}
+}
+
+// countSeq2 通过 iter.Seq2 依次返回 1..n
+func countSeq2(n int) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(i, nil) {
+				return
+			}
+		}
+	}
+}
+
+// postStream 以SSE方式调用流式工具，返回按顺序解码的事件消息
+func postStream(t *testing.T, handler http.Handler, name string, arguments map[string]interface{}) []map[string]interface{} {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      name,
+			"arguments": arguments,
+			"_meta":     map[string]interface{}{"progressToken": "p1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json, text/event-stream")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	var messages []map[string]interface{}
+	for _, event := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		data, ok := strings.CutPrefix(event, "event: message\ndata: ")
+		if !ok {
+			t.Fatalf("malformed event %q", event)
+		}
+		var message map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			t.Fatalf("decode event %q: %v", data, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestStreamingToolProgress(t *testing.T) {
+	tools := []types.Tool{
+		streamTool("chan", countChan),
+		streamTool("seq", countSeq),
+		streamTool("seq2", countSeq2),
+	}
+	mux := newTestMux(t, tools)
+
+	for _, tool := range tools {
+		t.Run(tool.Name, func(t *testing.T) {
+			messages := postStream(t, mux, tool.Name, map[string]interface{}{"n": 3})
+			if len(messages) != 4 {
+				t.Fatalf("messages = %v, want 3 progress notifications and the result", messages)
+			}
+			for i, message := range messages[:3] {
+				params, _ := message["params"].(map[string]interface{})
+				want := map[string]interface{}{"progressToken": "p1", "progress": float64(i + 1), "message": strconv.Itoa(i + 1)}
+				if message["method"] != "notifications/progress" || !reflect.DeepEqual(params, want) {
+					t.Errorf("message %d = %v, want progress %v", i, message, want)
+				}
+			}
+
+			final := messages[3]
+			result, _ := final["result"].(map[string]interface{})
+			content, _ := result["content"].([]interface{})
+			if final["id"] != float64(1) || len(content) != 1 {
+				t.Fatalf("final message = %v, want the call result", final)
+			}
+			if text := content[0].(map[string]interface{})["text"]; text != "[1 2 3]" {
+				t.Errorf("aggregated content = %v, want [1 2 3]", text)
+			}
+		})
+	}
+}
+
+func TestStreamingToolWithoutProgressToken(t *testing.T) {
+	mux := newTestMux(t, []types.Tool{streamTool("seq", countSeq)})
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("seq", map[string]interface{}{"n": 2}))
+	if got := resultText(t, resp); got != "[1 2]" {
+		t.Errorf("result = %q, want the aggregated [1 2]", got)
+	}
+}
+
+// drain 以 progress 为进度回调读取工具返回的流
+func drain(t *testing.T, ctx context.Context, handler interface{}, stream interface{}, progress progressFunc) (interface{}, error) {
+	t.Helper()
+	tool := &types.Tool{Name: "stream", Handler: handler}
+	sig, ok := streamSignature(tool)
+	if !ok {
+		t.Fatalf("%T is not a streaming handler", handler)
+	}
+	h := NewHTTPHandler(&testServer{})
+	return h.drainStream(withProgress(ctx, progress), tool, sig, reflect.ValueOf(stream))
+}
+
+func TestDrainStreamCancellationStopsProducer(t *testing.T) {
+	t.Run("chan", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stopped := make(chan struct{})
+		stream := func() <-chan int {
+			ch := make(chan int)
+			go func() {
+				defer close(stopped)
+				for i := 0; ; i++ {
+					select {
+					case ch <- i:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return ch
+		}()
+
+		received := 0
+		_, err := drain(t, ctx, countChan, stream, func(interface{}) {
+			if received++; received == 3 {
+				cancel()
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("drainStream() error = %v, want context.Canceled", err)
+		}
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("producer did not stop after cancellation")
+		}
+	})
+
+	t.Run("seq", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		produced := 0
+		stream := iter.Seq[string](func(yield func(string) bool) {
+			for {
+				produced++
+				if !yield("item") {
+					return
+				}
+			}
+		})
+
+		received := 0
+		_, err := drain(t, ctx, countSeq, stream, func(interface{}) {
+			if received++; received == 3 {
+				cancel()
+			}
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("drainStream() error = %v, want context.Canceled", err)
+		}
+		if produced != 3 {
+			t.Errorf("produced %d items, want the producer to stop after 3", produced)
+		}
+	})
+}
+
+func TestDrainStreamSeq2ErrorEndsStream(t *testing.T) {
+	failure := errors.New("backend unavailable")
+	resumed := false
+	stream := iter.Seq2[int, error](func(yield func(int, error) bool) {
+		if !yield(1, nil) {
+			return
+		}
+		if !yield(0, failure) {
+			return
+		}
+		resumed = true
+		yield(2, nil)
+	})
+
+	var items []interface{}
+	result, err := drain(t, context.Background(), countSeq2, stream, func(item interface{}) {
+		items = append(items, item)
+	})
+	if !errors.Is(err, failure) || result != nil {
+		t.Errorf("drainStream() = %v, %v, want %v", result, err, failure)
+	}
+	if resumed {
+		t.Error("producer continued after yielding an error")
+	}
+	if !reflect.DeepEqual(items, []interface{}{1}) {
+		t.Errorf("progress items = %v, want [1]", items)
+	}
+}
+
+func TestStreamingToolSeq2ErrorResult(t *testing.T) {
+	tool := streamTool("failing", func(n int) iter.Seq2[int, error] {
+		return func(yield func(int, error) bool) {
+			if yield(1, nil) {
+				yield(0, errors.New("backend unavailable"))
+			}
+		}
+	})
+	mux := newTestMux(t, []types.Tool{tool})
+
+	messages := postStream(t, mux, "failing", map[string]interface{}{"n": 1})
+	if len(messages) != 2 || messages[0]["method"] != "notifications/progress" {
+		t.Fatalf("messages = %v, want one progress notification and the result", messages)
+	}
+	result, _ := messages[1]["result"].(map[string]interface{})
+	if isError, _ := result["isError"].(bool); !isError {
+		t.Fatalf("final message = %v, want a tool error", messages[1])
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; !strings.Contains(text.(string), "backend unavailable") {
+		t.Errorf("error content = %v, want the stream error", text)
+	}
+}
+
+func TestStreamingToolTimeoutStopsProducer(t *testing.T) {
+	stopped := make(chan struct{})
+	tool := streamTool("endless", func(ctx context.Context, n int) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case ch <- n:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	})
+	tool.Timeout = 50 * time.Millisecond
+	mux := newTestMux(t, []types.Tool{tool})
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("endless", map[string]interface{}{"n": 1}))
+	if resp.Error != nil {
+		t.Fatalf("error = %+v", resp.Error)
+	}
+	if isError, _ := resp.Result["isError"].(bool); !isError {
+		t.Errorf("result = %v, want a timeout error", resp.Result)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("producer did not stop after the timeout")
+	}
+}
+
+func TestStreamSignatures(t *testing.T) {
+	tests := []struct {
+		handler interface{}
+		stream  scanner.StreamKind
+	}{
+		{countChan, scanner.StreamChan},
+		{countSeq, scanner.StreamSeq},
+		{countSeq2, scanner.StreamSeq2},
+	}
+	for _, tt := range tests {
+		sig, err := scanner.ParseSignature(reflect.TypeOf(tt.handler))
+		if err != nil || sig.Stream != tt.stream {
+			t.Errorf("ParseSignature(%T) = %+v, %v, want stream kind %v", tt.handler, sig, err, tt.stream)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/tracing"
+	"nacos-mcp-go/types"
+)
+
+// WithTracerProvider 设置创建span的 TracerProvider，未设置时使用 otel 的全局 TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(h *HTTPHandler) {
+		h.tracerProvider = tp
+	}
+}
+
+// tracer 返回处理器使用的Tracer
+func (h *HTTPHandler) tracer() trace.Tracer {
+	return tracing.Tracer(h.tracerProvider)
+}
+
+// traceContext 返回携带上游链路的请求上下文
+// 请求参数的 _meta 中带有 traceparent 时以其为准，否则读取HTTP请求头
+func traceContext(r *http.Request, params json.RawMessage) context.Context {
+	ctx := r.Context()
+	if meta := paramsMeta(params); tracing.HasTraceParent(meta) {
+		return tracing.Extract(ctx, meta)
+	}
+	return tracing.Extract(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+// paramsMeta 读取请求参数中的 _meta 对象
+func paramsMeta(params json.RawMessage) tracing.MetaCarrier {
+	var call struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if len(params) == 0 || json.Unmarshal(params, &call) != nil {
+		return nil
+	}
+	return call.Meta
+}
+
+// startRequestSpan 为JSON-RPC请求创建服务端span，tools/call 的span名称带有工具名
+func (h *HTTPHandler) startRequestSpan(r *http.Request, req *jsonrpcRequest) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("mcp.method.name", req.Method),
+		attribute.String("jsonrpc.request.id", requestID(req.ID)),
+	}
+	if session := sessionFromContext(r.Context()); session != "" {
+		attrs = append(attrs, attribute.String("mcp.session.id", session))
+	}
+
+	name := req.Method
+	if req.Method == "tools/call" {
+		if tool := callName(req.Params); tool != "" {
+			name += " " + tool
+			attrs = append(attrs, attribute.String("gen_ai.tool.name", tool))
+		}
+	}
+
+	return h.tracer().Start(traceContext(r, req.Params), name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endRequestSpan 记录JSON-RPC错误并结束span
+func endRequestSpan(span trace.Span, rpcErr *RPCError) {
+	if rpcErr != nil {
+		span.SetAttributes(
+			attribute.Int("rpc.jsonrpc.error_code", rpcErr.Code),
+			attribute.String("error.type", strconv.Itoa(rpcErr.Code)),
+		)
+		span.SetStatus(codes.Error, rpcErr.Message)
+	}
+	span.End()
+}
+
+// requestID 返回JSON-RPC请求ID的文本形式，字符串ID去掉引号
+func requestID(id json.RawMessage) string {
+	var s string
+	if json.Unmarshal(id, &s) == nil {
+		return s
+	}
+	return string(id)
+}
+
+// startToolSpan 为工具调用创建span
+func (h *HTTPHandler) startToolSpan(ctx context.Context, tool *types.Tool) (context.Context, trace.Span) {
+	return h.tracer().Start(ctx, "execute_tool "+tool.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "execute_tool"),
+			attribute.String("gen_ai.tool.name", tool.Name),
+		),
+	)
+}
+
+// endToolSpan 记录工具调用的状态并结束span
+func endToolSpan(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(
+			attribute.String("mcp.tool.status", "error"),
+			attribute.String("error.type", errorType(err)),
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("mcp.tool.status", "ok"))
+	}
+	span.End()
+}
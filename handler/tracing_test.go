@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/types"
+)
+
+const (
+	upstreamTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	upstreamSpanID  = "00f067aa0ba902b7"
+	metaTraceID     = "0af7651916cd43dd8448eb211c80319c"
+	metaSpanID      = "b7ad6b7169203331"
+)
+
+// newTracedMux 创建将span记录到 recorder 的处理器路由
+func newTracedMux(t *testing.T, tools []types.Tool) (*http.ServeMux, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	return newTestMux(t, tools, WithTracerProvider(tp)), recorder
+}
+
+// endedSpan 按名称查找已结束的span
+func endedSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+		names = append(names, span.Name())
+	}
+	t.Fatalf("no span %q, ended spans = %v", name, names)
+	return nil
+}
+
+// spanAttributes 返回span属性的文本形式
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+// assertAttributes 检查span带有 want 中的全部属性
+func assertAttributes(t *testing.T, span sdktrace.ReadOnlySpan, want map[attribute.Key]string) {
+	t.Helper()
+	attrs := spanAttributes(span)
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("span %q attribute %s = %q, want %q", span.Name(), key, attrs[key], value)
+		}
+	}
+}
+
+func TestToolsCallSpans(t *testing.T) {
+	failing := echoTool("fail")
+	failing.Invoke = func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+		return nil, errors.New("backend unavailable")
+	}
+	mux, recorder := newTracedMux(t, []types.Tool{echoTool("echo"), failing})
+
+	postRPC(t, mux, nil, "tools/call", callParams("echo", map[string]interface{}{"message": "hi"}))
+	server := endedSpan(t, recorder, "tools/call echo")
+	tool := endedSpan(t, recorder, "execute_tool echo")
+
+	if server.SpanKind() != trace.SpanKindServer || tool.SpanKind() != trace.SpanKindInternal {
+		t.Errorf("span kinds = %v, %v, want server and internal", server.SpanKind(), tool.SpanKind())
+	}
+	if tool.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("tool span is not a child of the JSON-RPC span")
+	}
+	assertAttributes(t, server, map[attribute.Key]string{
+		"mcp.method.name":    "tools/call",
+		"jsonrpc.request.id": "1",
+		"gen_ai.tool.name":   "echo",
+	})
+	assertAttributes(t, tool, map[attribute.Key]string{
+		"gen_ai.operation.name": "execute_tool",
+		"gen_ai.tool.name":      "echo",
+		"mcp.tool.status":       "ok",
+	})
+
+	postRPC(t, mux, nil, "tools/call", callParams("fail", nil))
+	failed := endedSpan(t, recorder, "execute_tool fail")
+	assertAttributes(t, failed, map[attribute.Key]string{"mcp.tool.status": "error"})
+	if failed.Status().Code != codes.Error || len(failed.Events()) == 0 {
+		t.Errorf("failed tool span status = %v, events = %d, want an error status and a recorded error", failed.Status(), len(failed.Events()))
+	}
+}
+
+func TestJSONRPCErrorSpan(t *testing.T) {
+	mux, recorder := newTracedMux(t, nil)
+
+	postRPC(t, mux, nil, "resources/unknown", nil)
+	span := endedSpan(t, recorder, "resources/unknown")
+	assertAttributes(t, span, map[attribute.Key]string{
+		"mcp.method.name":        "resources/unknown",
+		"rpc.jsonrpc.error_code": "-32601",
+	})
+	if span.Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", span.Status())
+	}
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	header := http.Header{"Traceparent": []string{"00-" + upstreamTraceID + "-" + upstreamSpanID + "-01"}}
+	meta := map[string]interface{}{"traceparent": "00-" + metaTraceID + "-" + metaSpanID + "-01"}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		meta    map[string]interface{}
+		traceID string
+		spanID  string
+	}{
+		{"http header", header, nil, upstreamTraceID, upstreamSpanID},
+		{"params _meta", nil, meta, metaTraceID, metaSpanID},
+		{"_meta takes precedence", header, meta, metaTraceID, metaSpanID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, recorder := newTracedMux(t, []types.Tool{echoTool("echo")})
+			params := callParams("echo", map[string]interface{}{"message": "hi"})
+			if tt.meta != nil {
+				params["_meta"] = tt.meta
+			}
+			postRPC(t, mux, tt.header, "tools/call", params)
+
+			server := endedSpan(t, recorder, "tools/call echo")
+			if got := server.SpanContext().TraceID().String(); got != tt.traceID {
+				t.Errorf("trace ID = %s, want %s", got, tt.traceID)
+			}
+			if got := server.Parent().SpanID().String(); got != tt.spanID || !server.Parent().IsRemote() {
+				t.Errorf("parent span = %s (remote %v), want remote %s", got, server.Parent().IsRemote(), tt.spanID)
+			}
+			if tool := endedSpan(t, recorder, "execute_tool echo"); tool.SpanContext().TraceID().String() != tt.traceID {
+				t.Errorf("tool span trace ID = %s, want %s", tool.SpanContext().TraceID(), tt.traceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/scanner"
+	"nacos-mcp-go/types"
+)
+
+// shape 注册为多态定义的接口
+type shape interface{ area() float64 }
+
+type circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c circle) area() float64 { return 3 * c.Radius * c.Radius }
+
+// rect 以指针类型注册的变体
+type rect struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func (r *rect) area() float64 { return r.Width * r.Height }
+
+// tagged 声明了判别属性字段的变体，解码后字段保留判别值
+type tagged struct {
+	Kind string  `json:"kind"`
+	Size float64 `json:"size"`
+}
+
+func (t tagged) area() float64 { return t.Size }
+
+// drawRequest 包含多态接口字段的参数
+type drawRequest struct {
+	Shape  shape   `json:"shape"`
+	Extras []shape `json:"extras,omitempty"`
+}
+
+// drawTool 扫描使用 shape 多态定义的工具，返回各图形的类型和面积
+func drawTool(t *testing.T) (types.Tool, *scanner.TypeRegistry) {
+	t.Helper()
+	registry := scanner.NewTypeRegistry()
+	err := registry.RegisterUnion(reflect.TypeOf((*shape)(nil)).Elem(), "kind", map[string]reflect.Type{
+		"circle": reflect.TypeOf(circle{}),
+		"rect":   reflect.TypeOf(&rect{}),
+		"tagged": reflect.TypeOf(tagged{}),
+	})
+	if err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+
+	info, err := scanner.ScanTool(func(req drawRequest) string {
+		parts := []string{fmt.Sprintf("%T %v", req.Shape, req.Shape.area())}
+		for _, extra := range req.Extras {
+			parts = append(parts, fmt.Sprintf("%+v", extra))
+		}
+		return strings.Join(parts, "; ")
+	}, scanner.WithTypeRegistry(registry))
+	if err != nil {
+		t.Fatalf("ScanTool() error = %v", err)
+	}
+	return types.Tool{
+		Name:        "draw",
+		InputSchema: info.InputSchema,
+		Handler:     info.Handler,
+		ParamNames:  info.ParamNames,
+	}, registry
+}
+
+func TestUnionArgumentDecoding(t *testing.T) {
+	tool, registry := drawTool(t)
+	mux := newTestMux(t, []types.Tool{tool}, WithTypeRegistry(registry))
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{
+			name:      "value variant",
+			arguments: map[string]interface{}{"shape": map[string]interface{}{"kind": "circle", "radius": 2}},
+			want:      "handler.circle 12",
+		},
+		{
+			name:      "pointer variant",
+			arguments: map[string]interface{}{"shape": map[string]interface{}{"kind": "rect", "width": 2, "height": 3}},
+			want:      "*handler.rect 6",
+		},
+		{
+			name: "discriminator field and array of unions",
+			arguments: map[string]interface{}{
+				"shape":  map[string]interface{}{"kind": "tagged", "size": 5},
+				"extras": []interface{}{map[string]interface{}{"kind": "tagged", "size": 1}},
+			},
+			want: "handler.tagged 5; {Kind:tagged Size:1}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := postRPC(t, mux, nil, "tools/call", callParams("draw", tt.arguments))
+			if got := resultText(t, resp); got != tt.want {
+				t.Errorf("result = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnionArgumentErrors(t *testing.T) {
+	tool, registry := drawTool(t)
+	mux := newTestMux(t, []types.Tool{tool}, WithTypeRegistry(registry))
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		path      string
+		message   string
+	}{
+		{
+			name:      "missing discriminator",
+			arguments: map[string]interface{}{"shape": map[string]interface{}{"radius": 2}},
+			path:      "/shape/kind",
+			message:   "is required",
+		},
+		{
+			name:      "unknown discriminator",
+			arguments: map[string]interface{}{"shape": map[string]interface{}{"kind": "triangle"}},
+			path:      "/shape/kind",
+			message:   `must be one of ["circle","rect","tagged"]`,
+		},
+		{
+			name:      "variant property",
+			arguments: map[string]interface{}{"shape": map[string]interface{}{"kind": "circle", "radius": "big"}},
+			path:      "/shape/radius",
+			message:   "must be number",
+		},
+		{
+			name: "array item",
+			arguments: map[string]interface{}{
+				"shape":  map[string]interface{}{"kind": "circle", "radius": 1},
+				"extras": []interface{}{map[string]interface{}{"kind": "circle", "radius": 1}, map[string]interface{}{}},
+			},
+			path:    "/extras/1/kind",
+			message: "is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := postRPC(t, mux, nil, "tools/call", callParams("draw", tt.arguments))
+			if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+				t.Fatalf("error = %+v, want %d", resp.Error, CodeInvalidParams)
+			}
+			issues, _ := resp.Error.Data["errors"].([]interface{})
+			if len(issues) == 0 {
+				t.Fatalf("errors = %v, want an issue at %s", resp.Error.Data["errors"], tt.path)
+			}
+			issue := issues[0].(map[string]interface{})
+			if issue["path"] != tt.path || !strings.HasPrefix(issue["message"].(string), tt.message) {
+				t.Errorf("issue = %v, want %s %q", issue, tt.path, tt.message)
+			}
+		})
+	}
+}
+
+func TestDecodeUnion(t *testing.T) {
+	_, registry := drawTool(t)
+	shapeType := reflect.TypeOf((*shape)(nil)).Elem()
+
+	// 未经schema校验时，解码器同样报告判别属性的错误
+	_, err := decoder{registry: registry}.decodeValue(map[string]interface{}{"kind": 1}, shapeType, "/shape")
+	if err == nil || !strings.Contains(err.Error(), "/shape/kind") {
+		t.Errorf("decodeValue() error = %v, want an issue at /shape/kind", err)
+	}
+	_, err = decoder{registry: registry}.decodeValue("circle", shapeType, "/shape")
+	if err == nil {
+		t.Error("decodeValue() of a string error = nil, want error")
+	}
+	// 未注册多态定义的接口无法解码
+	_, err = decoder{}.decodeValue(map[string]interface{}{"kind": "circle"}, shapeType, "/shape")
+	if err == nil {
+		t.Error("decodeValue() without a union error = nil, want error")
+	}
+}
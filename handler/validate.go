@@ -0,0 +1,528 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"nacos-mcp-go/scanner"
+)
+
+// uuidPattern UUID格式
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// patternCache 已编译的pattern缓存 map[string]*regexp.Regexp
+var patternCache sync.Map
+
+// ValidationIssue 单个参数校验问题
+type ValidationIssue struct {
+	Path    string `json:"path"`    // 参数的JSON Pointer路径，如 /user/name
+	Message string `json:"message"` // 问题描述
+}
+
+// ValidationError 参数校验错误，包含所有校验问题
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error 实现error接口
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		path := issue.Path
+		if path == "" {
+			path = "/"
+		}
+		msgs[i] = fmt.Sprintf("%s: %s", path, issue.Message)
+	}
+	return "invalid arguments: " + strings.Join(msgs, "; ")
+}
+
+// schemaValidator 按工具的 InputSchema 校验调用参数
+type schemaValidator struct {
+	root   map[string]interface{}
+	issues []ValidationIssue
+}
+
+// validateArguments 按schema校验调用参数，包括必填、类型、约束以及额外属性
+func validateArguments(schema map[string]interface{}, arguments map[string]interface{}) error {
+	v := &schemaValidator{root: schema}
+	v.validate(schema, arguments, "")
+	if len(v.issues) > 0 {
+		return &ValidationError{Issues: v.issues}
+	}
+	return nil
+}
+
+// validate 递归校验值及其子元素，类型不匹配时不再校验其余约束
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) {
+	schema = v.resolve(schema)
+	if schema == nil || value == nil {
+		return
+	}
+
+	if schemaType, ok := schema["type"].(string); ok && !matchesType(schemaType, value) {
+		v.addIssue(path, "must be %s, got %s", schemaType, jsonTypeName(value))
+		return
+	}
+
+	v.checkConstraints(schema, value, path)
+
+	if _, ok := schema["oneOf"]; ok {
+		v.validateOneOf(schema, value, path)
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, val, path)
+	case []interface{}:
+		if minItems, ok := toFloat(schema["minItems"]); ok && float64(len(val)) < minItems {
+			v.addIssue(path, "must contain at least %v items", schema["minItems"])
+		}
+		if maxItems, ok := toFloat(schema["maxItems"]); ok && float64(len(val)) > maxItems {
+			v.addIssue(path, "must contain at most %v items", schema["maxItems"])
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				v.validate(items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+}
+
+// validateObject 校验对象的必填属性、已声明属性以及额外属性
+func (v *schemaValidator) validateObject(schema map[string]interface{}, val map[string]interface{}, path string) {
+	for _, name := range toStrings(schema["required"]) {
+		if val[name] == nil {
+			v.addIssue(path+"/"+escapePointer(name), "is required")
+		}
+	}
+
+	// 按属性名排序，保证问题列表的顺序稳定
+	names := make([]string, 0, len(val))
+	for name := range val {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, name := range names {
+		propValue := val[name]
+		propPath := path + "/" + escapePointer(name)
+		if propSchema, ok := properties[name].(map[string]interface{}); ok {
+			v.validate(propSchema, propValue, propPath)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.addIssue(propPath, "is not a known property")
+			}
+		case map[string]interface{}:
+			v.validate(additional, propValue, propPath)
+		}
+	}
+}
+
+// validateOneOf 校验 oneOf，声明了判别属性时只按对应的变体校验，否则要求恰好匹配一个变体
+func (v *schemaValidator) validateOneOf(schema map[string]interface{}, value interface{}, path string) {
+	variants, _ := schema["oneOf"].([]interface{})
+
+	if property, ok := discriminatorProperty(schema); ok {
+		obj, isObject := value.(map[string]interface{})
+		if !isObject {
+			v.addIssue(path, "must be object, got %s", jsonTypeName(value))
+			return
+		}
+		propPath := path + "/" + escapePointer(property)
+		if obj[property] == nil {
+			v.addIssue(propPath, "is required")
+			return
+		}
+		variant := selectVariant(v.root, schema, obj)
+		if variant == nil {
+			v.addIssue(propPath, "must be one of %s", formatEnum(discriminatorValues(v.root, variants, property)))
+			return
+		}
+		v.validate(variant, value, path)
+		return
+	}
+
+	matched := 0
+	for _, item := range variants {
+		variant, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sub := &schemaValidator{root: v.root}
+		sub.validate(variant, value, path)
+		if len(sub.issues) == 0 {
+			matched++
+		}
+	}
+	switch {
+	case matched == 0:
+		v.addIssue(path, "must match one of %d schemas", len(variants))
+	case matched > 1:
+		v.addIssue(path, "must match exactly one schema, matched %d", matched)
+	}
+}
+
+// discriminatorProperty 返回schema声明的判别属性名
+func discriminatorProperty(schema map[string]interface{}) (string, bool) {
+	discriminator, _ := schema["discriminator"].(map[string]interface{})
+	property, ok := discriminator["propertyName"].(string)
+	return property, ok && property != ""
+}
+
+// selectVariant 按判别属性的值选择 oneOf 中的变体，变体的判别属性以 const 声明
+func selectVariant(root, schema map[string]interface{}, value map[string]interface{}) map[string]interface{} {
+	property, ok := discriminatorProperty(schema)
+	if !ok {
+		return nil
+	}
+	variants, _ := schema["oneOf"].([]interface{})
+	for _, item := range variants {
+		variant, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		variant = resolveRef(root, variant)
+		properties, _ := variant["properties"].(map[string]interface{})
+		propSchema, _ := properties[property].(map[string]interface{})
+		if constValue, ok := propSchema["const"]; ok && jsonEqual(constValue, value[property]) {
+			return variant
+		}
+	}
+	return nil
+}
+
+// discriminatorValues 返回各变体判别属性的取值
+func discriminatorValues(root map[string]interface{}, variants []interface{}, property string) []interface{} {
+	var values []interface{}
+	for _, item := range variants {
+		variant, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		properties, _ := resolveRef(root, variant)["properties"].(map[string]interface{})
+		propSchema, _ := properties[property].(map[string]interface{})
+		if constValue, ok := propSchema["const"]; ok {
+			values = append(values, constValue)
+		}
+	}
+	return values
+}
+
+// checkConstraints 校验 const、enum、minimum、maximum、minLength、maxLength、pattern、format 约束
+func (v *schemaValidator) checkConstraints(schema map[string]interface{}, value interface{}, path string) {
+	if constValue, ok := schema["const"]; ok && !jsonEqual(constValue, value) {
+		data, _ := json.Marshal(constValue)
+		v.addIssue(path, "must be %s", data)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		matched := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.addIssue(path, "must be one of %s", formatEnum(enum))
+		}
+	}
+
+	if num, ok := toFloat(value); ok {
+		if minimum, ok := toFloat(schema["minimum"]); ok && num < minimum {
+			v.addIssue(path, "must be >= %v", schema["minimum"])
+		}
+		if maximum, ok := toFloat(schema["maximum"]); ok && num > maximum {
+			v.addIssue(path, "must be <= %v", schema["maximum"])
+		}
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return
+	}
+
+	length := utf8.RuneCountInString(str)
+	if minLength, ok := toFloat(schema["minLength"]); ok && float64(length) < minLength {
+		v.addIssue(path, "length must be >= %v", schema["minLength"])
+	}
+	if maxLength, ok := toFloat(schema["maxLength"]); ok && float64(length) > maxLength {
+		v.addIssue(path, "length must be <= %v", schema["maxLength"])
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			v.addIssue(path, "invalid pattern %q in schema", pattern)
+		} else if !re.MatchString(str) {
+			v.addIssue(path, "must match pattern %q", pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok {
+		if err := checkFormat(format, str); err != nil {
+			v.addIssue(path, "must be a valid %s: %v", format, err)
+		}
+	}
+}
+
+// resolve 解析 $ref 引用
+func (v *schemaValidator) resolve(schema map[string]interface{}) map[string]interface{} {
+	return resolveRef(v.root, schema)
+}
+
+// resolveRef 解析 $ref 引用，只支持指向根schema中 $defs 的引用
+func resolveRef(root, schema map[string]interface{}) map[string]interface{} {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	defs, _ := root["$defs"].(map[string]interface{})
+	def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	return def
+}
+
+// applyDefaults 为缺失的参数填充schema中声明的默认值，嵌套对象中的缺失字段同样会被填充
+func applyDefaults(schema map[string]interface{}, arguments map[string]interface{}) map[string]interface{} {
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	fillDefaults(schema, schema, arguments)
+	return arguments
+}
+
+// fillDefaults 按对象schema递归填充默认值
+func fillDefaults(root, schema map[string]interface{}, value map[string]interface{}) {
+	schema = resolveRef(root, schema)
+	if variant := selectVariant(root, schema, value); variant != nil {
+		schema = variant
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, prop := range properties {
+		propSchema, ok := prop.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		current, exists := value[name]
+		if !exists || current == nil {
+			if def, ok := propSchema["default"]; ok {
+				// 对象和数组默认值复制后填充，中间件或工具函数修改参数时不会改变schema
+				value[name] = scanner.CopySchemaValue(def)
+			}
+			continue
+		}
+		if nested, ok := current.(map[string]interface{}); ok {
+			fillDefaults(root, propSchema, nested)
+		}
+	}
+}
+
+// addIssue 记录校验问题
+func (v *schemaValidator) addIssue(path, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkFormat 校验字符串格式，未知格式视为注解不做校验
+func checkFormat(format, value string) error {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "time":
+		_, err = time.Parse("15:04:05Z07:00", value)
+	case "duration":
+		_, err = time.ParseDuration(value)
+	case "email":
+		var addr *mail.Address
+		addr, err = mail.ParseAddress(value)
+		if err == nil && addr.Address != value {
+			err = fmt.Errorf("unexpected display name")
+		}
+	case "uri":
+		var u *url.URL
+		u, err = url.Parse(value)
+		if err == nil && u.Scheme == "" {
+			err = fmt.Errorf("missing scheme")
+		}
+	case "ipv4":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			err = fmt.Errorf("not an IPv4 address")
+		}
+	case "ipv6":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+			err = fmt.Errorf("not an IPv6 address")
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			err = fmt.Errorf("not a UUID")
+		}
+	}
+	return err
+}
+
+// matchesType 检查值是否为schema声明的JSON类型
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		num, ok := toFloat(value)
+		return ok && num == math.Trunc(num)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonTypeName 返回值对应的JSON类型名
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat(value); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toStrings 将schema中的字符串数组转换为 []string
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// compilePattern 编译并缓存正则表达式
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// toFloat 将JSON数值转换为float64
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// jsonEqual 按JSON语义比较两个值，数值不区分具体类型
+func jsonEqual(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, exists := y[k]
+			if !exists || !jsonEqual(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// formatEnum 格式化枚举值列表用于错误提示
+func formatEnum(enum []interface{}) string {
+	data, err := json.Marshal(enum)
+	if err != nil {
+		return fmt.Sprintf("%v", enum)
+	}
+	return string(data)
+}
+
+// escapePointer 按 RFC 6901 转义JSON Pointer中的路径片段
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
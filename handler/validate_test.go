@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/types"
+)
+
+// defaultsSchema 带有标量、对象、数组和嵌套默认值的schema
+func defaultsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"role":  map[string]interface{}{"type": "string", "default": "guest"},
+			"limit": map[string]interface{}{"type": "integer", "default": int64(20)},
+			"tags":  map[string]interface{}{"type": "array", "default": []interface{}{"a", "b"}},
+			"filter": map[string]interface{}{
+				"type":    "object",
+				"default": map[string]interface{}{"active": true},
+			},
+			"page": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"size": map[string]interface{}{"type": "integer", "default": int64(10)},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      map[string]interface{}
+	}{
+		{
+			name:      "nil arguments",
+			arguments: nil,
+			want: map[string]interface{}{
+				"role": "guest", "limit": int64(20), "tags": []interface{}{"a", "b"},
+				"filter": map[string]interface{}{"active": true},
+			},
+		},
+		{
+			name:      "provided values are kept",
+			arguments: map[string]interface{}{"role": "admin", "tags": []interface{}{}, "filter": nil},
+			want: map[string]interface{}{
+				"role": "admin", "limit": int64(20), "tags": []interface{}{},
+				"filter": map[string]interface{}{"active": true},
+			},
+		},
+		{
+			name:      "nested object",
+			arguments: map[string]interface{}{"page": map[string]interface{}{}},
+			want: map[string]interface{}{
+				"role": "guest", "limit": int64(20), "tags": []interface{}{"a", "b"},
+				"filter": map[string]interface{}{"active": true},
+				"page":   map[string]interface{}{"size": int64(10)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := applyDefaults(defaultsSchema(), tt.arguments); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: applyDefaults() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDefaultsCopiesCompositeDefaults(t *testing.T) {
+	schema := defaultsSchema()
+	arguments := applyDefaults(schema, nil)
+	arguments["tags"].([]interface{})[0] = "changed"
+	arguments["filter"].(map[string]interface{})["active"] = false
+
+	properties := schema["properties"].(map[string]interface{})
+	if tags := properties["tags"].(map[string]interface{})["default"].([]interface{}); tags[0] != "a" {
+		t.Errorf("tags default = %v, want the schema unchanged", tags)
+	}
+	if filter := properties["filter"].(map[string]interface{})["default"].(map[string]interface{}); filter["active"] != true {
+		t.Errorf("filter default = %v, want the schema unchanged", filter)
+	}
+}
+
+func TestDefaultsSurviveMutatingMiddleware(t *testing.T) {
+	tool := echoTool("search")
+	tool.InputSchema = defaultsSchema()
+	var seen []interface{}
+	mutate := func(next types.ToolHandlerFunc) types.ToolHandlerFunc {
+		return func(ctx context.Context, req *types.ToolRequest) (interface{}, error) {
+			filter := req.Arguments["filter"].(map[string]interface{})
+			seen = append(seen, filter["active"])
+			filter["active"] = "mutated"
+			return next(ctx, req)
+		}
+	}
+	mux := newTestMux(t, []types.Tool{tool}, WithMiddleware(mutate))
+
+	for i := 0; i < 2; i++ {
+		if _, resp := postRPC(t, mux, nil, "tools/call", callParams("search", nil)); resp.Error != nil {
+			t.Fatalf("tools/call error = %+v", resp.Error)
+		}
+	}
+	if seen[1] != true {
+		t.Errorf("second call saw filter.active = %v, want the default true", seen[1])
+	}
+}
+
+// userSchema 校验测试使用的schema
+func userSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 2, "maxLength": 8, "pattern": "^[a-z]+$"},
+			"age":   map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 150},
+			"score": map[string]interface{}{"type": "number"},
+			"admin": map[string]interface{}{"type": "boolean"},
+			"role":  map[string]interface{}{"type": "string", "enum": []interface{}{"admin", "guest"}},
+			"email": map[string]interface{}{"type": "string", "format": "email"},
+			"tags": map[string]interface{}{
+				"type": "array", "minItems": 1, "maxItems": 2,
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"address": map[string]interface{}{
+				"type":                 "object",
+				"required":             []interface{}{"city"},
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string"},
+				},
+			},
+			"labels": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "integer"},
+			},
+		},
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      []ValidationIssue // nil 表示校验通过
+	}{
+		{
+			name:      "valid",
+			arguments: map[string]interface{}{"name": "alice", "age": json.Number("30"), "tags": []interface{}{"x"}, "email": "a@example.com"},
+		},
+		{
+			name:      "missing required",
+			arguments: map[string]interface{}{},
+			want:      []ValidationIssue{{"/name", "is required"}},
+		},
+		{
+			name:      "null required",
+			arguments: map[string]interface{}{"name": nil},
+			want:      []ValidationIssue{{"/name", "is required"}},
+		},
+		{
+			name:      "wrong types",
+			arguments: map[string]interface{}{"name": json.Number("1"), "admin": "yes", "score": "high", "tags": "x"},
+			want: []ValidationIssue{
+				{"/admin", "must be boolean, got string"},
+				{"/name", "must be string, got number"},
+				{"/score", "must be number, got string"},
+				{"/tags", "must be array, got string"},
+			},
+		},
+		{
+			name:      "integer rejects fraction",
+			arguments: map[string]interface{}{"name": "bob", "age": json.Number("1.5")},
+			want:      []ValidationIssue{{"/age", "must be integer, got number"}},
+		},
+		{
+			name:      "numeric range",
+			arguments: map[string]interface{}{"name": "bob", "age": json.Number("151")},
+			want:      []ValidationIssue{{"/age", "must be <= 150"}},
+		},
+		{
+			name:      "string constraints",
+			arguments: map[string]interface{}{"name": "A"},
+			want: []ValidationIssue{
+				{"/name", "length must be >= 2"},
+				{"/name", `must match pattern "^[a-z]+$"`},
+			},
+		},
+		{
+			name:      "enum",
+			arguments: map[string]interface{}{"name": "bob", "role": "root"},
+			want:      []ValidationIssue{{"/role", `must be one of ["admin","guest"]`}},
+		},
+		{
+			name:      "array items and length",
+			arguments: map[string]interface{}{"name": "bob", "tags": []interface{}{"a", json.Number("2"), "c"}},
+			want: []ValidationIssue{
+				{"/tags", "must contain at most 2 items"},
+				{"/tags/1", "must be string, got number"},
+			},
+		},
+		{
+			name:      "nested object and additionalProperties false",
+			arguments: map[string]interface{}{"name": "bob", "address": map[string]interface{}{"zip": "1000"}},
+			want: []ValidationIssue{
+				{"/address/city", "is required"},
+				{"/address/zip", "is not a known property"},
+			},
+		},
+		{
+			name:      "additionalProperties schema",
+			arguments: map[string]interface{}{"name": "bob", "labels": map[string]interface{}{"a/b": "x", "ok": json.Number("1")}},
+			want:      []ValidationIssue{{"/labels/a~1b", "must be integer, got string"}},
+		},
+		{
+			name:      "format",
+			arguments: map[string]interface{}{"name": "bob", "email": "not-an-email"},
+			want:      []ValidationIssue{{"/email", "must be a valid email"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArguments(userSchema(), tt.arguments)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("validateArguments() error = %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("validateArguments() error = %v, want *ValidationError", err)
+			}
+			if len(validationErr.Issues) != len(tt.want) {
+				t.Fatalf("issues = %+v, want %+v", validationErr.Issues, tt.want)
+			}
+			for i, want := range tt.want {
+				got := validationErr.Issues[i]
+				if got.Path != want.Path || !strings.HasPrefix(got.Message, want.Message) {
+					t.Errorf("issue %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		format, value string
+		valid         bool
+	}{
+		{"date-time", "2025-01-02T15:04:05Z", true},
+		{"date-time", "2025-01-02", false},
+		{"date", "2025-01-02", true},
+		{"duration", "1h30m", true},
+		{"duration", "90", false},
+		{"email", "a@example.com", true},
+		{"email", "Alice <a@example.com>", false},
+		{"uri", "https://example.com/a", true},
+		{"uri", "/relative", false},
+		{"ipv4", "192.0.2.1", true},
+		{"ipv4", "2001:db8::1", false},
+		{"ipv6", "2001:db8::1", true},
+		{"ipv6", "192.0.2.1", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567", false},
+		{"unknown", "anything", true},
+	}
+	for _, tt := range tests {
+		if err := checkFormat(tt.format, tt.value); (err == nil) != tt.valid {
+			t.Errorf("checkFormat(%q, %q) error = %v, want valid %v", tt.format, tt.value, err, tt.valid)
+		}
+	}
+}
+
+func TestToolsCallReportsInvalidParams(t *testing.T) {
+	tool := echoTool("create_user")
+	tool.InputSchema = userSchema()
+	mux := newTestMux(t, []types.Tool{tool})
+
+	_, resp := postRPC(t, mux, nil, "tools/call", callParams("create_user", map[string]interface{}{"age": -1}))
+	if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+		t.Fatalf("error = %+v, want %d", resp.Error, CodeInvalidParams)
+	}
+	issues, _ := resp.Error.Data["errors"].([]interface{})
+	var paths []string
+	for _, issue := range issues {
+		paths = append(paths, issue.(map[string]interface{})["path"].(string))
+	}
+	if want := []string{"/name", "/age"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("error paths = %v, want %v", paths, want)
+	}
+
+	_, resp = postRPC(t, mux, nil, "tools/call", map[string]interface{}{"name": "create_user", "arguments": "x"})
+	if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+		t.Errorf("non-object arguments: error = %+v, want %d", resp.Error, CodeInvalidParams)
+	}
+}
+
+func TestInvokeReportsInvalidParams(t *testing.T) {
+	tool := echoTool("create_user")
+	tool.InputSchema = userSchema()
+	mux := newTestMux(t, []types.Tool{tool})
+
+	r := httptest.NewRequest(http.MethodPost, "/mcp/tools/create_user/invoke", strings.NewReader(`{"arguments":{"name":"x1"}}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var body struct {
+		Error RPCError `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != CodeInvalidParams {
+		t.Errorf("code = %d, want %d", body.Error.Code, CodeInvalidParams)
+	}
+}
@@ -0,0 +1,252 @@
+package nacosmcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFormat 清单文件格式
+type ManifestFormat string
+
+const (
+	ManifestYAML ManifestFormat = "yaml"
+	ManifestJSON ManifestFormat = "json"
+)
+
+// Manifest 工具元数据清单，用于在不修改代码的情况下覆盖扫描得到的工具信息
+// Tools 以扫描得到的工具名为键
+type Manifest struct {
+	Tools map[string]*ToolManifest `json:"tools" yaml:"tools"`
+}
+
+// ToolManifest 单个工具的覆盖配置，未设置的字段保留扫描结果
+type ToolManifest struct {
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty"` // 重命名工具
+	Title       string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"` // 参数名到参数描述
+	Annotations *ToolAnnotations  `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// LoadManifest 从文件加载清单，按扩展名识别格式，.json 为JSON，其余按YAML解析
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest failed: %w", err)
+	}
+
+	manifest, err := ParseManifest(data, ManifestFormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest %s failed: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// ParseManifest 解析清单内容，未知字段会返回错误
+func ParseManifest(data []byte, format ManifestFormat) (*Manifest, error) {
+	manifest := &Manifest{}
+
+	switch format {
+	case ManifestJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(manifest); err != nil {
+			return nil, err
+		}
+	case ManifestYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(manifest); err != nil && err != io.EOF {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported manifest format %q", format)
+	}
+
+	for name, tool := range manifest.Tools {
+		if tool == nil {
+			return nil, fmt.Errorf("tool %q has an empty entry", name)
+		}
+	}
+	return manifest, nil
+}
+
+// ManifestFormatOf 根据文件扩展名返回清单格式
+func ManifestFormatOf(path string) ManifestFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ManifestJSON
+	}
+	return ManifestYAML
+}
+
+// NewManifest 根据工具列表生成清单，可作为编辑的初始文件
+// 清单以扫描得到的工具名为键，重名时为添加前缀后的名称；工具已被重命名时记录在 name 中，重新加载后得到相同的工具名
+func NewManifest(tools []Tool) *Manifest {
+	manifest := &Manifest{Tools: make(map[string]*ToolManifest, len(tools))}
+
+	for _, tool := range tools {
+		entry := &ToolManifest{
+			Title:       tool.Title,
+			Description: tool.Description,
+			Annotations: tool.Annotations,
+		}
+
+		properties, _ := tool.InputSchema["properties"].(map[string]interface{})
+		if len(properties) > 0 {
+			entry.Parameters = make(map[string]string, len(properties))
+			for name, property := range properties {
+				description := ""
+				if schema, ok := property.(map[string]interface{}); ok {
+					description, _ = schema["description"].(string)
+				}
+				entry.Parameters[name] = description
+			}
+		}
+
+		key := tool.ScannedName
+		if key == "" {
+			key = tool.Name
+		}
+		if tool.Name != key {
+			entry.Name = tool.Name
+		}
+		manifest.Tools[key] = entry
+	}
+	return manifest
+}
+
+// Write 按指定格式输出清单
+func (m *Manifest) Write(w io.Writer, format ManifestFormat) error {
+	switch format {
+	case ManifestJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(m)
+	case ManifestYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("unsupported manifest format %q", format)
+	}
+}
+
+// applyManifest 将清单中的覆盖配置应用到工具，返回匹配到的清单项名称
+func (s *Server) applyManifest(tool *Tool) (string, error) {
+	if s.manifest == nil {
+		return "", nil
+	}
+	key := tool.ScannedName
+	entry, ok := s.manifest.Tools[key]
+	if !ok {
+		return "", nil
+	}
+
+	if len(entry.Parameters) > 0 {
+		schema, err := overrideParameters(tool.InputSchema, entry.Parameters)
+		if err != nil {
+			return "", fmt.Errorf("manifest entry %q: %w", key, err)
+		}
+		tool.InputSchema = schema
+	}
+	if entry.Title != "" {
+		tool.Title = entry.Title
+	}
+	if entry.Description != "" {
+		tool.Description = entry.Description
+	}
+	if entry.Annotations != nil {
+		tool.Annotations = mergeAnnotations(tool.Annotations, entry.Annotations)
+	}
+
+	if entry.Name != "" {
+		tool.Name = entry.Name
+	}
+	return key, nil
+}
+
+// mergeAnnotations 用清单中声明的行为提示覆盖工具已有的行为提示
+func mergeAnnotations(base, override *ToolAnnotations) *ToolAnnotations {
+	merged := &ToolAnnotations{}
+	if base != nil {
+		*merged = *base
+	}
+	if override.ReadOnlyHint != nil {
+		merged.ReadOnlyHint = override.ReadOnlyHint
+	}
+	if override.DestructiveHint != nil {
+		merged.DestructiveHint = override.DestructiveHint
+	}
+	if override.IdempotentHint != nil {
+		merged.IdempotentHint = override.IdempotentHint
+	}
+	if override.OpenWorldHint != nil {
+		merged.OpenWorldHint = override.OpenWorldHint
+	}
+	return merged
+}
+
+// overrideParameters 返回替换了参数描述的输入schema，不修改原schema
+// 参数名必须存在于schema中，描述为空时保留原描述
+func overrideParameters(schema map[string]interface{}, descriptions map[string]string) (map[string]interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	result := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		result[k] = v
+	}
+	overridden := make(map[string]interface{}, len(properties))
+	for k, v := range properties {
+		overridden[k] = v
+	}
+
+	for name, description := range descriptions {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		if description == "" {
+			continue
+		}
+		copied := make(map[string]interface{}, len(property)+1)
+		for k, v := range property {
+			copied[k] = v
+		}
+		copied["description"] = description
+		overridden[name] = copied
+	}
+
+	result["properties"] = overridden
+	return result, nil
+}
+
+// validateManifest 校验清单中的每一项都匹配到了已注册的工具
+func (s *Server) validateManifest() error {
+	if s.manifest == nil {
+		return nil
+	}
+
+	var unmatched []string
+	for name := range s.manifest.Tools {
+		if !s.manifestUsed[name] {
+			unmatched = append(unmatched, name)
+		}
+	}
+	if len(unmatched) > 0 {
+		sort.Strings(unmatched)
+		return fmt.Errorf("manifest entries do not match any registered tool: %s", strings.Join(unmatched, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package nacosmcp
+
+import (
+	"bytes"
+	"testing"
+)
+
+// SearchUsersRequest 搜索用户的参数
+type SearchUsersRequest struct {
+	Keyword string `json:"keyword"`
+}
+
+// SearchUsers 按关键字搜索用户
+func SearchUsers(req SearchUsersRequest) []string { return nil }
+
+func TestManifestRoundTrip(t *testing.T) {
+	manifest, err := ParseManifest([]byte(`
+tools:
+  search_users:
+    name: find_users
+    description: Find users by keyword
+    parameters:
+      keyword: Keyword matched against the user name
+`), ManifestYAML)
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+
+	server := NewServer("users", WithManifest(manifest))
+	if err := server.RegisterTool(SearchUsers); err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+	if err := server.validateManifest(); err != nil {
+		t.Fatalf("validateManifest() error = %v", err)
+	}
+	if got := server.GetTools()[0].Name; got != "find_users" {
+		t.Fatalf("tool name = %q, want find_users", got)
+	}
+
+	generated := server.Manifest()
+	entry, ok := generated.Tools["search_users"]
+	if !ok {
+		t.Fatalf("generated manifest keys = %v, want scanned name search_users", generated.Tools)
+	}
+	if entry.Name != "find_users" {
+		t.Errorf("entry name = %q, want find_users", entry.Name)
+	}
+
+	// 生成的清单重新加载后匹配相同的工具，得到相同的工具名
+	var buf bytes.Buffer
+	if err := generated.Write(&buf, ManifestYAML); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	reloaded, err := ParseManifest(buf.Bytes(), ManifestYAML)
+	if err != nil {
+		t.Fatalf("ParseManifest() of generated manifest error = %v", err)
+	}
+	again := NewServer("users", WithManifest(reloaded))
+	if err := again.RegisterTool(SearchUsers); err != nil {
+		t.Fatalf("RegisterTool() with generated manifest error = %v", err)
+	}
+	if err := again.validateManifest(); err != nil {
+		t.Fatalf("validateManifest() with generated manifest error = %v", err)
+	}
+	tool := again.GetTools()[0]
+	if tool.Name != "find_users" || tool.Description != "Find users by keyword" {
+		t.Errorf("reloaded tool = %q %q, want find_users with manifest description", tool.Name, tool.Description)
+	}
+}
+
+func TestManifestUnrenamedTool(t *testing.T) {
+	server := NewServer("users")
+	if err := server.RegisterTool(SearchUsers); err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+	entry, ok := server.Manifest().Tools["search_users"]
+	if !ok {
+		t.Fatal("generated manifest has no entry for search_users")
+	}
+	if entry.Name != "" {
+		t.Errorf("entry name = %q, want empty for a tool that was not renamed", entry.Name)
+	}
+}
+
+// UserService 与 OrderService 都有 Echo 方法
+type UserService struct{}
+
+// Echo 返回消息
+func (UserService) Echo(message string) string { return message }
+
+type OrderService struct{}
+
+// Echo 返回消息
+func (OrderService) Echo(message string) string { return message }
+
+func TestManifestRoundTripPrefixedDuplicate(t *testing.T) {
+	server := NewServer("shop", WithDuplicatePolicy(DuplicatePrefix))
+	for _, service := range []interface{}{UserService{}, OrderService{}} {
+		if err := server.RegisterService(service); err != nil {
+			t.Fatalf("RegisterService(%T) error = %v", service, err)
+		}
+	}
+
+	generated := server.Manifest()
+	if len(generated.Tools) != 2 || generated.Tools["echo"] == nil || generated.Tools["order_service_echo"] == nil {
+		t.Fatalf("generated manifest keys = %v, want echo and order_service_echo", generated.Tools)
+	}
+	generated.Tools["echo"].Description = "Echo a user message"
+	generated.Tools["order_service_echo"].Description = "Echo an order message"
+
+	var buf bytes.Buffer
+	if err := generated.Write(&buf, ManifestYAML); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	reloaded, err := ParseManifest(buf.Bytes(), ManifestYAML)
+	if err != nil {
+		t.Fatalf("ParseManifest() of generated manifest error = %v", err)
+	}
+	again := NewServer("shop", WithDuplicatePolicy(DuplicatePrefix), WithManifest(reloaded))
+	for _, service := range []interface{}{UserService{}, OrderService{}} {
+		if err := again.RegisterService(service); err != nil {
+			t.Fatalf("RegisterService(%T) with generated manifest error = %v", service, err)
+		}
+	}
+	if err := again.validateManifest(); err != nil {
+		t.Fatalf("validateManifest() with generated manifest error = %v", err)
+	}
+
+	want := map[string]string{
+		"echo":               "Echo a user message",
+		"order_service_echo": "Echo an order message",
+	}
+	tools := again.GetTools()
+	if len(tools) != len(want) {
+		t.Fatalf("tools = %d, want %d", len(tools), len(want))
+	}
+	for _, tool := range tools {
+		if tool.Description != want[tool.Name] {
+			t.Errorf("tool %q description = %q, want %q", tool.Name, tool.Description, want[tool.Name])
+		}
+	}
+}
+
+func TestDuplicateScannedNameRejected(t *testing.T) {
+	server := NewServer("shop")
+	if err := server.RegisterService(UserService{}); err != nil {
+		t.Fatalf("RegisterService() error = %v", err)
+	}
+	if err := server.RegisterService(OrderService{}); err == nil {
+		t.Error("RegisterService() error = nil, want duplicate echo rejected")
+	}
+}
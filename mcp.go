@@ -2,17 +2,37 @@ package nacosmcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/audit"
+	"nacos-mcp-go/auth"
 	"nacos-mcp-go/handler"
 	"nacos-mcp-go/httpclient"
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/ratelimit"
 	"nacos-mcp-go/scanner"
 	"nacos-mcp-go/types"
 )
 
 type Protocol = types.Protocol
 type Tool = types.Tool
+type ToolAnnotations = types.ToolAnnotations
+type OutputFormat = types.OutputFormat
+type ResultFormatter = types.ResultFormatter
+type ToolRequest = types.ToolRequest
+type ToolHandlerFunc = types.ToolHandlerFunc
+type ToolMiddleware = types.ToolMiddleware
+type AuthRule = types.AuthRule
+type RateLimit = types.RateLimit
 
 const (
 	ProtocolStdio      = types.ProtocolStdio
@@ -20,18 +40,59 @@ const (
 	ProtocolStreamHTTP = types.ProtocolStreamHTTP
 )
 
+const (
+	OutputText        = types.OutputText
+	OutputJSON        = types.OutputJSON
+	OutputJSONCompact = types.OutputJSONCompact
+	OutputMarkdown    = types.OutputMarkdown
+	OutputYAML        = types.OutputYAML
+)
+
+// DuplicatePolicy 工具重名处理策略
+type DuplicatePolicy int
+
+const (
+	// DuplicateReject 拒绝注册重名工具（默认）
+	DuplicateReject DuplicatePolicy = iota
+	// DuplicatePrefix 重名时自动添加前缀：服务使用结构体名，单个函数使用服务器名
+	DuplicatePrefix
+)
+
 // Server MCP服务器实例
 type Server struct {
-	name       string
-	namespace  string
-	group      string
-	ip         string
-	port       int
-	protocol   Protocol
-	tools      []Tool
-	metadata   map[string]string
-	httpServer *httpclient.Server
-	running    bool
+	name            string
+	namespace       string
+	group           string
+	ip              string
+	port            int
+	protocol        Protocol
+	tools           []Tool
+	duplicatePolicy DuplicatePolicy
+	typeRegistry    *scanner.TypeRegistry
+	manifest        *Manifest
+	manifestUsed    map[string]bool
+	defaultFormat   OutputFormat
+	formatters      map[OutputFormat]ResultFormatter
+	middleware      []ToolMiddleware
+	authenticators  []auth.Authenticator
+	resource        *auth.ResourceMetadata
+	metrics         *metrics.Metrics
+	metricsPath     string
+	tracerProvider  trace.TracerProvider
+	defaultTimeout  time.Duration
+	maxConcurrency  int
+	queueTimeout    time.Duration
+	logger          *log.Logger
+	rateLimitStore  ratelimit.Store
+	principalLimit  RateLimit
+	sessionLimit    RateLimit
+	cacheSize       int
+	sessionTimeout  time.Duration
+	maxSessions     int
+	auditSinks      []audit.Sink
+	metadata        map[string]string
+	httpServer      *httpclient.Server
+	running         bool
 }
 
 type Option func(*Server)
@@ -77,15 +138,324 @@ func WithProtocol(protocol Protocol) Option {
 	}
 }
 
+// WithDuplicatePolicy 设置工具重名处理策略
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(s *Server) {
+		s.duplicatePolicy = policy
+	}
+}
+
+// WithTypeSchema 为第三方类型指定JSON Schema，sample 为该类型的任意值
+// 如 WithTypeSchema(decimal.Decimal{}, map[string]interface{}{"type": "string", "format": "decimal"})
+func WithTypeSchema(sample interface{}, schema map[string]interface{}) Option {
+	return func(s *Server) {
+		s.typeRegistry.RegisterSchema(reflect.TypeOf(sample), schema)
+	}
+}
+
+// WithTypeDecoder 为第三方类型指定参数解码器，sample 为该类型的任意值
+// decode 接收参数的JSON编码，返回值必须可赋值给该类型
+func WithTypeDecoder(sample interface{}, decode func(data json.RawMessage) (interface{}, error)) Option {
+	return func(s *Server) {
+		s.typeRegistry.RegisterDecoder(reflect.TypeOf(sample), decode)
+	}
+}
+
+// WithManifest 设置工具元数据清单，注册工具时按清单覆盖名称、描述、参数描述和行为提示
+// 启动时清单中未匹配到已注册工具的项会导致启动失败
+func WithManifest(manifest *Manifest) Option {
+	return func(s *Server) {
+		s.manifest = manifest
+	}
+}
+
+// WithResultFormat 设置工具返回值默认的文本格式，工具未指定格式时使用
+func WithResultFormat(format OutputFormat) Option {
+	return func(s *Server) {
+		s.defaultFormat = format
+	}
+}
+
+// WithFormatter 注册自定义结果格式，工具可通过 output=name 或 WithOutputFormat 使用
+func WithFormatter(format OutputFormat, formatter ResultFormatter) Option {
+	return func(s *Server) {
+		s.formatters[format] = formatter
+	}
+}
+
+// WithMiddleware 添加服务器级工具中间件，作用于所有工具，先添加的中间件在外层
+func WithMiddleware(middleware ...ToolMiddleware) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// WithAuthenticator 添加认证器，设置后所有 MCP 接口都要求认证，按添加顺序尝试
+func WithAuthenticator(authenticators ...auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithResourceMetadata 作为OAuth 2.1资源服务器公开受保护资源元数据，MCP客户端据此向授权服务器申请令牌
+// Resource 为空时使用 http://ip:port/mcp，ScopesSupported 为空时使用各工具要求的 scope
+// 未通过 auth.WithAudience 设置受众的JWT和令牌自省认证器只接受 aud 包含 Resource 的令牌
+func WithResourceMetadata(metadata auth.ResourceMetadata) Option {
+	return func(s *Server) {
+		s.resource = &metadata
+	}
+}
+
+// WithMetrics 设置Prometheus指标，记录工具调用、活跃会话和流式调用
+// 注册客户端可通过 registry.WithMetrics 使用同一组指标
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithMetricsRoute 在 MCP 接口旁挂载指标接口，如 /metrics，需同时设置 WithMetrics
+func WithMetricsRoute(path string) Option {
+	return func(s *Server) {
+		s.metricsPath = path
+	}
+}
+
+// WithTracerProvider 设置链路追踪的 TracerProvider，为每个JSON-RPC请求和工具调用创建span
+// 未设置时使用 otel 的全局 TracerProvider；注册客户端可通过 registry.WithTracerProvider 使用同一个
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.tracerProvider = tp
+	}
+}
+
+// WithDefaultTimeout 设置工具调用的默认超时，工具可通过 timeout tag 或 WithToolTimeout 单独设置
+// 超时的调用以 isError 结果返回，为0时不限制
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.defaultTimeout = timeout
+	}
+}
+
+// WithMaxConcurrency 限制所有工具同时执行的调用数，超出的调用排队等待，为0时不限制
+func WithMaxConcurrency(n int) Option {
+	return func(s *Server) {
+		s.maxConcurrency = n
+	}
+}
+
+// WithQueueTimeout 设置调用排队等待执行名额的最长时间，超时后返回繁忙错误
+// 为0时一直等待到调用超时或客户端断开
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.queueTimeout = timeout
+	}
+}
+
+// WithLogger 设置服务器的日志输出，工具调用失败和panic的堆栈写入该日志，未设置时使用 log.Default()
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithPrincipalRateLimit 为每个已认证的调用方设置跨所有工具的限流，如 ratelimit.PerMinute(600)
+func WithPrincipalRateLimit(limit RateLimit) Option {
+	return func(s *Server) {
+		s.principalLimit = limit
+	}
+}
+
+// WithSessionRateLimit 为每个 initialize 签发的会话设置跨所有工具的限流
+// 没有有效会话的请求按已认证调用方限流，匿名请求按客户端地址限流
+func WithSessionRateLimit(limit RateLimit) Option {
+	return func(s *Server) {
+		s.sessionLimit = limit
+	}
+}
+
+// WithRateLimitStore 设置保存令牌桶状态的存储，多个实例共享限流时使用，默认为进程内存储
+func WithRateLimitStore(store ratelimit.Store) Option {
+	return func(s *Server) {
+		s.rateLimitStore = store
+	}
+}
+
+// WithCacheSize 设置工具结果缓存的最大条目数，超出时淘汰最久未使用的条目，默认 1024
+func WithCacheSize(size int) Option {
+	return func(s *Server) {
+		s.cacheSize = size
+	}
+}
+
+// WithSessionTimeout 设置 initialize 签发的会话在没有请求后保留的时长，默认 30 分钟
+func WithSessionTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.sessionTimeout = timeout
+	}
+}
+
+// WithMaxSessions 设置同时保留的最大会话数，超出时淘汰最久没有请求的会话，默认 10000
+func WithMaxSessions(n int) Option {
+	return func(s *Server) {
+		s.maxSessions = n
+	}
+}
+
+// WithAuditSink 添加审计输出，每次工具调用结束后写入调用方、会话、工具、脱敏后的参数、结果状态、耗时和 trace ID
+// 参数中以 mcp:"sensitive" 标记的字段被脱敏
+func WithAuditSink(sink audit.Sink) Option {
+	return func(s *Server) {
+		s.auditSinks = append(s.auditSinks, sink)
+	}
+}
+
+// ToolOption 工具注册选项
+type ToolOption func(*Tool)
+
+// WithToolName 设置工具名，匿名函数必须指定
+func WithToolName(name string) ToolOption {
+	return func(t *Tool) {
+		t.Name = name
+	}
+}
+
+// WithToolDescription 设置工具描述
+func WithToolDescription(description string) ToolOption {
+	return func(t *Tool) {
+		t.Description = description
+	}
+}
+
+// WithToolTitle 设置展示给用户的工具名称
+func WithToolTitle(title string) ToolOption {
+	return func(t *Tool) {
+		t.Title = title
+	}
+}
+
+// WithReadOnlyHint 声明工具是否只读，不修改环境
+func WithReadOnlyHint(readOnly bool) ToolOption {
+	return func(t *Tool) {
+		toolAnnotations(t).ReadOnlyHint = &readOnly
+	}
+}
+
+// WithDestructiveHint 声明工具是否可能执行破坏性更新
+func WithDestructiveHint(destructive bool) ToolOption {
+	return func(t *Tool) {
+		toolAnnotations(t).DestructiveHint = &destructive
+	}
+}
+
+// WithIdempotentHint 声明相同参数重复调用工具是否没有额外影响
+func WithIdempotentHint(idempotent bool) ToolOption {
+	return func(t *Tool) {
+		toolAnnotations(t).IdempotentHint = &idempotent
+	}
+}
+
+// WithOpenWorldHint 声明工具是否与外部系统交互
+func WithOpenWorldHint(openWorld bool) ToolOption {
+	return func(t *Tool) {
+		toolAnnotations(t).OpenWorldHint = &openWorld
+	}
+}
+
+// WithOutputFormat 设置工具返回值的文本格式
+func WithOutputFormat(format OutputFormat) ToolOption {
+	return func(t *Tool) {
+		t.OutputFormat = format
+	}
+}
+
+// WithToolFormatter 设置工具返回值的自定义格式化函数
+func WithToolFormatter(formatter ResultFormatter) ToolOption {
+	return func(t *Tool) {
+		t.Formatter = formatter
+	}
+}
+
+// WithToolMiddleware 添加工具级中间件，在服务器级中间件之内执行，先添加的中间件在外层
+func WithToolMiddleware(middleware ...ToolMiddleware) ToolOption {
+	return func(t *Tool) {
+		t.Middleware = append(t.Middleware, middleware...)
+	}
+}
+
+// WithAllowedPrincipals 只允许指定主体调用工具
+func WithAllowedPrincipals(subjects ...string) ToolOption {
+	return func(t *Tool) {
+		rule := toolAuthRule(t)
+		rule.Principals = append(rule.Principals, subjects...)
+	}
+}
+
+// WithRequiredScopes 要求调用方具备全部指定的 scope
+func WithRequiredScopes(scopes ...string) ToolOption {
+	return func(t *Tool) {
+		rule := toolAuthRule(t)
+		rule.Scopes = append(rule.Scopes, scopes...)
+	}
+}
+
+// WithToolTimeout 设置工具的调用超时，优先于服务器的默认超时
+func WithToolTimeout(timeout time.Duration) ToolOption {
+	return func(t *Tool) {
+		t.Timeout = timeout
+	}
+}
+
+// WithToolConcurrency 限制工具同时执行的调用数，超出的调用排队等待
+func WithToolConcurrency(n int) ToolOption {
+	return func(t *Tool) {
+		t.MaxConcurrency = n
+	}
+}
+
+// WithToolRateLimit 设置所有调用方共享的工具限流，如 ratelimit.PerSecond(10)
+func WithToolRateLimit(limit RateLimit) ToolOption {
+	return func(t *Tool) {
+		t.RateLimit = &limit
+	}
+}
+
+// WithToolCache 缓存工具的调用结果，适用于相同参数返回相同结果的查询类工具
+// 结果按工具名、调用方和参数缓存 ttl 时长，失败的调用不缓存；缓存位于中间件链最内层，命中时中间件仍然执行
+func WithToolCache(ttl time.Duration) ToolOption {
+	return func(t *Tool) {
+		t.CacheTTL = ttl
+	}
+}
+
+// toolAuthRule 返回工具的访问规则，未设置时创建
+func toolAuthRule(t *Tool) *AuthRule {
+	if t.Auth == nil {
+		t.Auth = &AuthRule{}
+	}
+	return t.Auth
+}
+
+// toolAnnotations 返回工具的行为提示，未设置时创建
+func toolAnnotations(t *Tool) *ToolAnnotations {
+	if t.Annotations == nil {
+		t.Annotations = &ToolAnnotations{}
+	}
+	return t.Annotations
+}
+
 // NewServer 创建MCP服务器
 func NewServer(name string, opts ...Option) *Server {
 	server := &Server{
-		name:     name,
-		group:    "DEFAULT_GROUP",
-		ip:       "127.0.0.1",
-		port:     8080,
-		protocol: ProtocolSSE, // 默认使用SSE协议
-		metadata: make(map[string]string),
+		name:         name,
+		group:        "DEFAULT_GROUP",
+		ip:           "127.0.0.1",
+		port:         8080,
+		protocol:     ProtocolSSE, // 默认使用SSE协议
+		typeRegistry: scanner.NewTypeRegistry(),
+		manifestUsed: make(map[string]bool),
+		formatters:   make(map[OutputFormat]ResultFormatter),
+		metadata:     make(map[string]string),
 	}
 
 	for _, opt := range opts {
@@ -96,20 +466,25 @@ func NewServer(name string, opts ...Option) *Server {
 }
 
 // RegisterTool 注册单个工具函数
-func (s *Server) RegisterTool(handler interface{}) error {
+func (s *Server) RegisterTool(handler interface{}, opts ...ToolOption) error {
 	toolInfo, err := s.scanTool(handler)
 	if err != nil {
 		return fmt.Errorf("scan tool failed: %w", err)
 	}
 
-	tool := Tool{
-		Name:        toolInfo.Name,
-		Description: toolInfo.Description,
-		InputSchema: toolInfo.InputSchema,
-		Handler:     toolInfo.Handler,
+	tool := newTool(toolInfo)
+
+	for _, opt := range opts {
+		opt(&tool)
+	}
+
+	if tool.Name == "" {
+		return fmt.Errorf("register tool failed: anonymous function requires a tool name, use WithToolName")
 	}
 
-	s.tools = append(s.tools, tool)
+	if err := s.addTools([]Tool{tool}, s.name); err != nil {
+		return fmt.Errorf("register tool failed: %w", err)
+	}
 	return nil
 }
 
@@ -120,16 +495,115 @@ func (s *Server) RegisterService(service interface{}) error {
 		return fmt.Errorf("scan service failed: %w", err)
 	}
 
+	tools := make([]Tool, 0, len(toolInfos))
 	for _, toolInfo := range toolInfos {
-		tool := Tool{
-			Name:        toolInfo.Name,
-			Description: toolInfo.Description,
-			InputSchema: toolInfo.InputSchema,
-			Handler:     toolInfo.Handler,
+		tools = append(tools, newTool(toolInfo))
+	}
+
+	// 任意工具校验失败时整个服务都不注册
+	if err := s.addTools(tools, serviceName(service)); err != nil {
+		return fmt.Errorf("register service failed: %w", err)
+	}
+	return nil
+}
+
+// addTools 应用清单覆盖并处理重名后添加工具，任意工具失败时不添加任何工具
+func (s *Server) addTools(tools []Tool, prefix string) error {
+	scanned := make(map[string]bool, len(s.tools)+len(tools))
+	for _, tool := range s.tools {
+		scanned[tool.ScannedName] = true
+	}
+
+	matched := make([]string, 0, len(tools))
+	for i := range tools {
+		if tools[i].ScannedName == "" {
+			tools[i].ScannedName = tools[i].Name
+		}
+		// 扫描得到的工具名是清单的键，重名时先按重名策略添加前缀，保证每个工具对应唯一的清单项
+		if scanned[tools[i].ScannedName] {
+			if s.duplicatePolicy != DuplicatePrefix || prefix == "" {
+				return fmt.Errorf("tool %q is already registered", tools[i].ScannedName)
+			}
+			name := prefix + "_" + tools[i].ScannedName
+			if scanned[name] {
+				return fmt.Errorf("tool %q is already registered", name)
+			}
+			tools[i].Name = name
+			tools[i].ScannedName = name
+		}
+		scanned[tools[i].ScannedName] = true
+
+		key, err := s.applyManifest(&tools[i])
+		if err != nil {
+			return err
+		}
+		if key != "" {
+			matched = append(matched, key)
 		}
-		s.tools = append(s.tools, tool)
 	}
 
+	tools, err := s.resolveToolNames(tools, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range matched {
+		s.manifestUsed[key] = true
+	}
+	s.tools = append(s.tools, tools...)
+	return nil
+}
+
+// resolveToolNames 校验工具名并按重名策略处理冲突
+func (s *Server) resolveToolNames(tools []Tool, prefix string) ([]Tool, error) {
+	taken := make(map[string]bool, len(s.tools)+len(tools))
+	for _, tool := range s.tools {
+		taken[tool.Name] = true
+	}
+
+	for i := range tools {
+		name := tools[i].Name
+		if err := scanner.ValidateToolName(name); err != nil {
+			return nil, err
+		}
+
+		if taken[name] {
+			if s.duplicatePolicy != DuplicatePrefix || prefix == "" {
+				return nil, fmt.Errorf("tool %q is already registered", name)
+			}
+			name = prefix + "_" + name
+			if err := scanner.ValidateToolName(name); err != nil {
+				return nil, fmt.Errorf("prefix duplicate tool %q failed: %w", tools[i].Name, err)
+			}
+			if taken[name] {
+				return nil, fmt.Errorf("tool %q is already registered", name)
+			}
+		}
+
+		tools[i].Name = name
+		taken[name] = true
+	}
+
+	return tools, nil
+}
+
+// RegisterUnion 为接口类型注册具体实现，需在注册使用该接口的工具之前调用
+// iface 为接口的nil指针，如 (*Shape)(nil)；variants 为判别值到具体类型示例值的映射，如 {"circle": Circle{}}
+// 工具schema中该接口输出为 oneOf，调用时按 discriminator 属性的值解码为对应的具体类型
+func (s *Server) RegisterUnion(iface interface{}, discriminator string, variants map[string]interface{}) error {
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType == nil || ifaceType.Kind() != reflect.Ptr || ifaceType.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("register union failed: iface must be a nil pointer to an interface, such as (*Shape)(nil)")
+	}
+
+	variantTypes := make(map[string]reflect.Type, len(variants))
+	for value, sample := range variants {
+		variantTypes[value] = reflect.TypeOf(sample)
+	}
+
+	if err := s.typeRegistry.RegisterUnion(ifaceType.Elem(), discriminator, variantTypes); err != nil {
+		return fmt.Errorf("register union failed: %w", err)
+	}
 	return nil
 }
 
@@ -139,10 +613,23 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("server is already running")
 	}
 
+	if err := s.validateManifest(); err != nil {
+		return err
+	}
+	if err := s.validateFormats(); err != nil {
+		return err
+	}
+	if err := s.validateAuth(); err != nil {
+		return err
+	}
+	if s.metricsPath != "" && s.metrics == nil {
+		return fmt.Errorf("metrics route %s requires metrics, use WithMetrics", s.metricsPath)
+	}
+
 	// 只有非stdio协议才需要启动HTTP服务器
 	if s.protocol != ProtocolStdio {
 		// 创建HTTP处理器
-		httpHandler := handler.NewHTTPHandler(s)
+		httpHandler := handler.NewHTTPHandler(s, s.handlerOptions()...)
 		mux := http.NewServeMux()
 		httpHandler.RegisterRoutes(mux)
 
@@ -162,6 +649,131 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
+// handlerOptions 返回传递给处理器的服务器配置
+func (s *Server) handlerOptions() []handler.Option {
+	metadata := s.resourceMetadata()
+	opts := []handler.Option{
+		handler.WithTypeRegistry(s.typeRegistry),
+		handler.WithDefaultFormat(s.defaultFormat),
+		handler.WithMiddleware(s.middleware...),
+		handler.WithAuthenticators(s.handlerAuthenticators(metadata)...),
+		handler.WithMetrics(s.metrics),
+		handler.WithMetricsRoute(s.metricsPath),
+		handler.WithTracerProvider(s.tracerProvider),
+		handler.WithDefaultTimeout(s.defaultTimeout),
+		handler.WithMaxConcurrency(s.maxConcurrency),
+		handler.WithQueueTimeout(s.queueTimeout),
+		handler.WithLogger(s.logger),
+		handler.WithRateLimitStore(s.rateLimitStore),
+		handler.WithPrincipalRateLimit(s.principalLimit),
+		handler.WithSessionRateLimit(s.sessionLimit),
+		handler.WithCacheSize(s.cacheSize),
+		handler.WithSessionTimeout(s.sessionTimeout),
+		handler.WithMaxSessions(s.maxSessions),
+		handler.WithAuditSinks(s.auditSinks...),
+	}
+	if metadata != nil {
+		opts = append(opts, handler.WithResourceMetadata(metadata))
+	}
+	for format, formatter := range s.formatters {
+		opts = append(opts, handler.WithFormatter(format, formatter))
+	}
+	return opts
+}
+
+// handlerAuthenticators 返回处理器使用的认证器
+// 作为OAuth资源服务器时，未设置受众的令牌认证器以资源标识作为受众，避免接受为其他资源签发的令牌
+func (s *Server) handlerAuthenticators(metadata *auth.ResourceMetadata) []auth.Authenticator {
+	if metadata == nil {
+		return s.authenticators
+	}
+	authenticators := make([]auth.Authenticator, len(s.authenticators))
+	for i, authenticator := range s.authenticators {
+		if binder, ok := authenticator.(auth.AudienceBinder); ok {
+			authenticator = binder.BindAudience(metadata.Resource)
+		}
+		authenticators[i] = authenticator
+	}
+	return authenticators
+}
+
+// validateAuth 校验声明了访问规则的工具都能完成认证
+func (s *Server) validateAuth() error {
+	if s.resource != nil {
+		if len(s.resource.AuthorizationServers) == 0 {
+			return fmt.Errorf("resource metadata requires at least one authorization server")
+		}
+		if len(s.authenticators) == 0 {
+			return fmt.Errorf("resource metadata requires an authenticator to validate access tokens, use WithAuthenticator")
+		}
+	}
+	if len(s.authenticators) > 0 {
+		return nil
+	}
+	if s.principalLimit.Rate > 0 {
+		return fmt.Errorf("principal rate limit requires an authenticator, use WithAuthenticator")
+	}
+	for _, tool := range s.tools {
+		if tool.Auth != nil && (len(tool.Auth.Principals) > 0 || len(tool.Auth.Scopes) > 0) {
+			return fmt.Errorf("tool %s declares access rules but no authenticator is configured, use WithAuthenticator", tool.Name)
+		}
+	}
+	return nil
+}
+
+// resourceMetadata 返回填充默认值后的受保护资源元数据，未配置时返回nil
+func (s *Server) resourceMetadata() *auth.ResourceMetadata {
+	if s.resource == nil {
+		return nil
+	}
+
+	metadata := *s.resource
+	if metadata.Resource == "" {
+		metadata.Resource = fmt.Sprintf("http://%s:%d/mcp", s.ip, s.port)
+	}
+	if len(metadata.BearerMethodsSupported) == 0 {
+		metadata.BearerMethodsSupported = []string{"header"}
+	}
+	if len(metadata.ScopesSupported) == 0 {
+		for _, tool := range s.tools {
+			if tool.Auth == nil {
+				continue
+			}
+			for _, scope := range tool.Auth.Scopes {
+				if !slices.Contains(metadata.ScopesSupported, scope) {
+					metadata.ScopesSupported = append(metadata.ScopesSupported, scope)
+				}
+			}
+		}
+		sort.Strings(metadata.ScopesSupported)
+	}
+	return &metadata
+}
+
+// validateFormats 校验默认格式和各工具指定的格式均已定义
+func (s *Server) validateFormats() error {
+	known := func(format OutputFormat) bool {
+		if format == "" {
+			return true
+		}
+		if _, ok := s.formatters[format]; ok {
+			return true
+		}
+		_, ok := handler.BuiltinFormatter(format)
+		return ok
+	}
+
+	if !known(s.defaultFormat) {
+		return fmt.Errorf("unknown default output format %q", s.defaultFormat)
+	}
+	for _, tool := range s.tools {
+		if tool.Formatter == nil && !known(tool.OutputFormat) {
+			return fmt.Errorf("tool %q uses unknown output format %q", tool.Name, tool.OutputFormat)
+		}
+	}
+	return nil
+}
+
 // Stop 停止服务器
 func (s *Server) Stop(ctx context.Context) error {
 	if !s.running {
@@ -204,11 +816,38 @@ func (s *Server) GetTools() []Tool {
 	return s.tools
 }
 
+// Manifest 根据已注册的工具生成清单，可作为编辑的初始文件
+func (s *Server) Manifest() *Manifest {
+	return NewManifest(s.tools)
+}
+
 // GetMetadata 获取元数据
 func (s *Server) GetMetadata() map[string]string {
 	return s.metadata
 }
 
+// GetSecuritySchemes 获取调用服务器所需的认证方式，注册到Nacos供客户端发现
+func (s *Server) GetSecuritySchemes() []types.SecurityScheme {
+	var schemes []types.SecurityScheme
+	seen := make(map[string]bool)
+	add := func(scheme types.SecurityScheme) {
+		if !seen[scheme.ID] {
+			seen[scheme.ID] = true
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	if metadata := s.resourceMetadata(); metadata != nil {
+		add(metadata.SecurityScheme())
+	}
+	for _, authenticator := range s.authenticators {
+		if provider, ok := authenticator.(auth.SchemeProvider); ok {
+			add(provider.SecurityScheme())
+		}
+	}
+	return schemes
+}
+
 // GetProtocol 获取协议类型
 func (s *Server) GetProtocol() Protocol {
 	return s.protocol
@@ -221,10 +860,41 @@ func (s *Server) IsRunning() bool {
 
 // scanTool 扫描单个工具函数
 func (s *Server) scanTool(handler interface{}) (*scanner.ToolInfo, error) {
-	return scanner.ScanTool(handler)
+	return scanner.ScanTool(handler, scanner.WithTypeRegistry(s.typeRegistry))
 }
 
 // scanStruct 扫描结构体方法
 func (s *Server) scanStruct(service interface{}) ([]*scanner.ToolInfo, error) {
-	return scanner.ScanStruct(service)
+	return scanner.ScanStruct(service, scanner.WithTypeRegistry(s.typeRegistry))
+}
+
+// newTool 由扫描结果创建工具，RegisterTool 和 RegisterService 共用
+func newTool(toolInfo *scanner.ToolInfo) Tool {
+	return Tool{
+		Name:           toolInfo.Name,
+		Title:          toolInfo.Title,
+		Description:    toolInfo.Description,
+		InputSchema:    toolInfo.InputSchema,
+		Handler:        toolInfo.Handler,
+		ParamNames:     toolInfo.ParamNames,
+		Annotations:    toolInfo.Annotations,
+		OutputFormat:   toolInfo.OutputFormat,
+		Auth:           toolInfo.Auth,
+		Timeout:        toolInfo.Timeout,
+		MaxConcurrency: toolInfo.MaxConcurrency,
+		RateLimit:      toolInfo.RateLimit,
+		CacheTTL:       toolInfo.CacheTTL,
+	}
+}
+
+// serviceName 获取服务对象的类型名，用作重名工具的前缀
+func serviceName(service interface{}) string {
+	t := reflect.TypeOf(service)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return scanner.ToSnakeCase(t.Name())
 }
@@ -0,0 +1,51 @@
+package nacosmcp
+
+import (
+	"reflect"
+	"testing"
+
+	"nacos-mcp-go/scanner"
+)
+
+func TestNewToolCopiesEveryToolInfoField(t *testing.T) {
+	var info scanner.ToolInfo
+	infoValue := reflect.ValueOf(&info).Elem()
+	for i := 0; i < infoValue.NumField(); i++ {
+		setNonZero(t, infoValue.Field(i), infoValue.Type().Field(i).Name)
+	}
+
+	tool := reflect.ValueOf(newTool(&info))
+	for i := 0; i < infoValue.NumField(); i++ {
+		name := infoValue.Type().Field(i).Name
+		field := tool.FieldByName(name)
+		if !field.IsValid() {
+			t.Errorf("Tool has no field %s of ToolInfo", name)
+			continue
+		}
+		if !reflect.DeepEqual(field.Interface(), infoValue.Field(i).Interface()) {
+			t.Errorf("newTool did not copy %s", name)
+		}
+	}
+}
+
+// setNonZero 为字段设置非零值，用于检查字段是否被复制
+func setNonZero(t *testing.T, v reflect.Value, name string) {
+	t.Helper()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(7)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(name))
+	case reflect.Slice:
+		v.Set(reflect.Append(reflect.MakeSlice(v.Type(), 0, 1), reflect.ValueOf(name)))
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(name))
+	default:
+		t.Fatalf("field %s has unsupported kind %s", name, v.Kind())
+	}
+}
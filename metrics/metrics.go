@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "mcp"
+
+// DefaultSessionIdleTimeout 会话在没有请求后仍计为活跃的时长
+const DefaultSessionIdleTimeout = 5 * time.Minute
+
+// Metrics MCP服务器的Prometheus指标，nil 值的所有方法均为空操作
+type Metrics struct {
+	gatherer prometheus.Gatherer
+
+	toolCalls     *prometheus.CounterVec
+	toolErrors    *prometheus.CounterVec
+	toolPanics    *prometheus.CounterVec
+	cacheHits     *prometheus.CounterVec
+	cacheMisses   *prometheus.CounterVec
+	toolDuration  *prometheus.HistogramVec
+	requestSize   *prometheus.HistogramVec
+	responseSize  *prometheus.HistogramVec
+	activeStreams prometheus.Gauge
+	registrations *prometheus.CounterVec
+
+	sessions *sessionTracker
+}
+
+// Option 指标配置选项
+type Option func(*config)
+
+// config 指标配置
+type config struct {
+	registerer  prometheus.Registerer
+	gatherer    prometheus.Gatherer
+	sessionIdle time.Duration
+}
+
+// WithRegistry 将指标注册到指定的注册表，/metrics 接口也从该注册表收集
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(c *config) {
+		c.registerer = registry
+		c.gatherer = registry
+	}
+}
+
+// WithSessionIdleTimeout 设置会话在没有请求后仍计为活跃的时长
+func WithSessionIdleTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.sessionIdle = timeout
+	}
+}
+
+// New 创建并注册指标，默认注册到 prometheus.DefaultRegisterer
+func New(opts ...Option) (*Metrics, error) {
+	cfg := &config{
+		registerer:  prometheus.DefaultRegisterer,
+		gatherer:    prometheus.DefaultGatherer,
+		sessionIdle: DefaultSessionIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	sizeBuckets := prometheus.ExponentialBuckets(64, 4, 8)
+	m := &Metrics{
+		gatherer: cfg.gatherer,
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "Number of tool calls by tool and status.",
+		}, []string{"tool", "status"}),
+		toolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_errors_total",
+			Help:      "Number of failed tool calls by tool and error type.",
+		}, []string{"tool", "type"}),
+		toolPanics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_panics_total",
+			Help:      "Number of panics recovered from tool functions, middleware and formatters.",
+		}, []string{"tool"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_cache_hits_total",
+			Help:      "Number of tool calls answered from the result cache.",
+		}, []string{"tool"}),
+		cacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_cache_misses_total",
+			Help:      "Number of calls to cached tools that were not found in the result cache.",
+		}, []string{"tool"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Duration of tool calls, including argument validation and result formatting.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_request_size_bytes",
+			Help:      "Size of the JSON encoded tool call arguments.",
+			Buckets:   sizeBuckets,
+		}, []string{"tool"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_response_size_bytes",
+			Help:      "Size of the JSON encoded tool call results.",
+			Buckets:   sizeBuckets,
+		}, []string{"tool"}),
+		activeStreams: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_streams",
+			Help:      "Number of tool calls currently streaming progress notifications.",
+		}),
+		registrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registry_operations_total",
+			Help:      "Number of Nacos registry operations by operation and result.",
+		}, []string{"operation", "result"}),
+		sessions: newSessionTracker(cfg.sessionIdle),
+	}
+
+	activeSessions := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of client sessions with a request within the session idle timeout.",
+	}, func() float64 {
+		return float64(m.sessions.count())
+	})
+
+	collectors := []prometheus.Collector{
+		m.toolCalls, m.toolErrors, m.toolPanics, m.cacheHits, m.cacheMisses, m.toolDuration, m.requestSize, m.responseSize,
+		m.activeStreams, activeSessions, m.registrations,
+	}
+	for _, collector := range collectors {
+		if err := cfg.registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler 返回 /metrics 接口的处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
+
+// ObserveToolCall 记录一次工具调用，errorType 为空表示调用成功
+func (m *Metrics) ObserveToolCall(tool string, duration time.Duration, requestSize, responseSize int, errorType string) {
+	if m == nil {
+		return
+	}
+
+	status := "ok"
+	if errorType != "" {
+		status = "error"
+		m.toolErrors.WithLabelValues(tool, errorType).Inc()
+	}
+	m.toolCalls.WithLabelValues(tool, status).Inc()
+	m.toolDuration.WithLabelValues(tool).Observe(duration.Seconds())
+	m.requestSize.WithLabelValues(tool).Observe(float64(requestSize))
+	if errorType == "" {
+		m.responseSize.WithLabelValues(tool).Observe(float64(responseSize))
+	}
+}
+
+// ObservePanic 记录一次从工具调用中恢复的panic
+func (m *Metrics) ObservePanic(tool string) {
+	if m == nil {
+		return
+	}
+	m.toolPanics.WithLabelValues(tool).Inc()
+}
+
+// ObserveCache 记录一次结果缓存的命中或未命中
+func (m *Metrics) ObserveCache(tool string, hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.cacheHits.WithLabelValues(tool).Inc()
+	} else {
+		m.cacheMisses.WithLabelValues(tool).Inc()
+	}
+}
+
+// StreamStarted 记录开始推送进度通知的流式调用，返回结束时调用的函数
+func (m *Metrics) StreamStarted() func() {
+	if m == nil {
+		return func() {}
+	}
+	m.activeStreams.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(m.activeStreams.Dec)
+	}
+}
+
+// TouchSession 记录会话的一次请求，应只传入服务端签发的会话ID
+func (m *Metrics) TouchSession(session string) {
+	if m == nil || session == "" {
+		return
+	}
+	m.sessions.touch(session)
+}
+
+// ObserveRegistryOperation 记录一次注册中心操作的结果
+func (m *Metrics) ObserveRegistryOperation(operation string, err error) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.registrations.WithLabelValues(operation, result).Inc()
+}
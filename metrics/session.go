@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTrackedSessions 同时统计的最大会话数，已满时不再记录新的会话
+const maxTrackedSessions = 100000
+
+// sessionTracker 按最近一次请求的时间统计活跃会话
+type sessionTracker struct {
+	mu       sync.Mutex
+	idle     time.Duration
+	size     int
+	lastSeen map[string]time.Time
+	pruned   time.Time
+	now      func() time.Time
+}
+
+// newSessionTracker 创建会话统计
+func newSessionTracker(idle time.Duration) *sessionTracker {
+	return &sessionTracker{
+		idle:     idle,
+		size:     maxTrackedSessions,
+		lastSeen: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// touch 记录会话的请求时间，每个空闲周期清理一次超时的会话，避免长期不被采集时无限增长
+// 统计的会话数已满时先清理超时的会话，仍然已满则不记录新的会话
+func (t *sessionTracker) touch(session string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.pruned) >= t.idle {
+		t.prune(now)
+	}
+	if _, ok := t.lastSeen[session]; !ok && len(t.lastSeen) >= t.size {
+		t.prune(now)
+		if len(t.lastSeen) >= t.size {
+			return
+		}
+	}
+	t.lastSeen[session] = now
+}
+
+// count 清理空闲超时的会话并返回活跃会话数
+func (t *sessionTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(t.now())
+	return len(t.lastSeen)
+}
+
+// prune 删除空闲超时的会话
+func (t *sessionTracker) prune(now time.Time) {
+	deadline := now.Add(-t.idle)
+	for session, seen := range t.lastSeen {
+		if seen.Before(deadline) {
+			delete(t.lastSeen, session)
+		}
+	}
+	t.pruned = now
+}
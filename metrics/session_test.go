@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSessionTrackerExpiresIdleSessions(t *testing.T) {
+	now := time.Now()
+	tracker := newSessionTracker(time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	tracker.touch("a")
+	now = now.Add(30 * time.Second)
+	tracker.touch("b")
+	if got := tracker.count(); got != 2 {
+		t.Fatalf("count() = %d, want 2", got)
+	}
+
+	now = now.Add(45 * time.Second)
+	if got := tracker.count(); got != 1 {
+		t.Errorf("count() = %d after a became idle, want 1", got)
+	}
+}
+
+func TestSessionTrackerCapsTrackedSessions(t *testing.T) {
+	now := time.Now()
+	tracker := newSessionTracker(time.Minute)
+	tracker.now = func() time.Time { return now }
+	tracker.size = 3
+
+	for i := 0; i < 5; i++ {
+		tracker.touch(fmt.Sprintf("s%d", i))
+	}
+	if got := tracker.count(); got != 3 {
+		t.Fatalf("count() = %d, want the cap of 3", got)
+	}
+
+	tracker.touch("s0")
+	if _, ok := tracker.lastSeen["s4"]; ok {
+		t.Error("tracker recorded a new session beyond the cap")
+	}
+
+	now = now.Add(2 * time.Minute)
+	tracker.touch("s4")
+	if got := tracker.count(); got != 1 {
+		t.Errorf("count() = %d after idle sessions were pruned, want 1", got)
+	}
+}
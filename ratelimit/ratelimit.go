@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nacos-mcp-go/types"
+)
+
+// Store 保存令牌桶的状态，多个服务器实例共享限流时可基于Redis等外部存储实现
+type Store interface {
+	// Allow 从 key 对应的令牌桶取出一个令牌，令牌不足时返回 false 以及补足一个令牌需要等待的时长
+	Allow(ctx context.Context, key string, limit types.RateLimit) (bool, time.Duration, error)
+}
+
+// Refunder 可由 Store 实现，退还 Allow 取出的令牌
+// 一次调用需要多个令牌桶放行时，被后面的令牌桶拒绝的调用借此退还已取出的令牌，不消耗配额
+type Refunder interface {
+	// Refund 向 key 对应的令牌桶退还一个令牌，令牌数不超过突发次数
+	Refund(ctx context.Context, key string, limit types.RateLimit) error
+}
+
+// PerSecond 每秒 n 次，允许瞬时突发 n 次
+func PerSecond(n int) types.RateLimit {
+	return types.RateLimit{Rate: float64(n), Burst: n}
+}
+
+// PerMinute 每分钟 n 次，允许瞬时突发 n 次
+func PerMinute(n int) types.RateLimit {
+	return types.RateLimit{Rate: float64(n) / 60, Burst: n}
+}
+
+// PerHour 每小时 n 次，允许瞬时突发 n 次
+func PerHour(n int) types.RateLimit {
+	return types.RateLimit{Rate: float64(n) / 3600, Burst: n}
+}
+
+// Parse 解析形如 10/s、100/m、1000/h 的限流配置，突发次数等于单位时间内的次数
+func Parse(s string) (types.RateLimit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || err != nil || n <= 0 {
+		return types.RateLimit{}, fmt.Errorf("invalid rate limit %q: must be like 10/s, 100/m or 1000/h", s)
+	}
+	switch strings.TrimSpace(unit) {
+	case "s":
+		return PerSecond(n), nil
+	case "m":
+		return PerMinute(n), nil
+	case "h":
+		return PerHour(n), nil
+	}
+	return types.RateLimit{}, fmt.Errorf("invalid rate limit %q: unit must be s, m or h", s)
+}
+
+// bucket 令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+// refill 按经过的时间补充令牌
+func (b *bucket) refill(now time.Time) float64 {
+	return math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+}
+
+// MemoryStore 进程内的令牌桶存储
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// sweepInterval 清理已补满的令牌桶的间隔
+const sweepInterval = time.Minute
+
+// NewMemoryStore 创建进程内的令牌桶存储，已补满的令牌桶会被定期清理
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Allow 实现 Store 接口
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit types.RateLimit) (bool, time.Duration, error) {
+	if limit.Rate <= 0 {
+		return true, 0, nil
+	}
+	burst := float64(max(limit.Burst, 1))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	b.rate, b.burst = limit.Rate, burst
+	b.tokens = b.refill(now)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// Refund 实现 Refunder 接口
+func (s *MemoryStore) Refund(ctx context.Context, key string, limit types.RateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 已被清理的令牌桶视为已补满
+	if b, ok := s.buckets[key]; ok {
+		b.tokens = math.Min(b.burst, b.tokens+1)
+	}
+	return nil
+}
+
+// sweep 删除空闲时间足以补满的令牌桶，这些桶与新建的桶没有区别
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if b.refill(now) >= b.burst {
+			delete(s.buckets, key)
+		}
+	}
+}
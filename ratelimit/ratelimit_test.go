@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    types.RateLimit
+		wantErr bool
+	}{
+		{in: "10/s", want: PerSecond(10)},
+		{in: " 100 / m ", want: PerMinute(100)},
+		{in: "1000/h", want: PerHour(1000)},
+		{in: "10", wantErr: true},
+		{in: "0/s", wantErr: true},
+		{in: "-1/m", wantErr: true},
+		{in: "10/d", wantErr: true},
+		{in: "x/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %+v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMemoryStoreAllowsBurstThenWaits(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := types.RateLimit{Rate: 1, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, err := store.Allow(ctx, "k", limit); !allowed || err != nil {
+			t.Fatalf("call %d: allowed = %v, err = %v, want allowed within the burst", i, allowed, err)
+		}
+	}
+	allowed, wait, err := store.Allow(ctx, "k", limit)
+	if allowed || err != nil {
+		t.Fatalf("allowed = %v, err = %v, want rejected after the burst", allowed, err)
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("wait = %s, want up to one second at 1/s", wait)
+	}
+
+	if allowed, _, _ := store.Allow(ctx, "other", limit); !allowed {
+		t.Error("separate key shares the bucket")
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := types.RateLimit{Rate: 100, Burst: 1}
+
+	if allowed, _, _ := store.Allow(ctx, "k", limit); !allowed {
+		t.Fatal("first call rejected")
+	}
+	if allowed, _, _ := store.Allow(ctx, "k", limit); allowed {
+		t.Fatal("second call allowed before refill")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if allowed, _, _ := store.Allow(ctx, "k", limit); !allowed {
+		t.Error("call rejected after the bucket refilled")
+	}
+}
+
+func TestMemoryStoreUnlimited(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 10; i++ {
+		if allowed, _, _ := store.Allow(context.Background(), "k", types.RateLimit{}); !allowed {
+			t.Fatal("zero rate limit rejected a call")
+		}
+	}
+}
+
+func TestMemoryStoreRefund(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := types.RateLimit{Rate: 0.001, Burst: 2}
+
+	store.Allow(ctx, "k", limit)
+	store.Allow(ctx, "k", limit)
+	if allowed, _, _ := store.Allow(ctx, "k", limit); allowed {
+		t.Fatal("call allowed after the burst")
+	}
+	if err := store.Refund(ctx, "k", limit); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if allowed, _, _ := store.Allow(ctx, "k", limit); !allowed {
+		t.Error("refunded token was not available")
+	}
+
+	// 退还不超过突发次数
+	for i := 0; i < 5; i++ {
+		store.Refund(ctx, "k", limit)
+		store.Refund(ctx, "missing", limit)
+	}
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := store.Allow(ctx, "k", limit); !allowed {
+			t.Fatalf("call %d rejected within the burst", i)
+		}
+	}
+	if allowed, _, _ := store.Allow(ctx, "k", limit); allowed {
+		t.Error("refunds raised the bucket above its burst")
+	}
+}
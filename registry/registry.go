@@ -11,6 +11,10 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/metrics"
+	"nacos-mcp-go/tracing"
 	"nacos-mcp-go/types"
 )
 
@@ -23,6 +27,7 @@ type Client struct {
 	timeout     time.Duration
 	httpClient  *http.Client
 	accessToken string
+	metrics     *metrics.Metrics
 }
 
 // Option 客户端配置选项
@@ -51,6 +56,21 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithMetrics 设置Prometheus指标，记录注册和注销的成功与失败次数
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// WithTracerProvider 为访问Nacos的每个HTTP请求创建客户端span，并通过 traceparent 请求头传播链路
+// tp 为空时使用 otel 的全局 TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = tracing.NewTransport(c.httpClient.Transport, tp)
+	}
+}
+
 // NewClient 创建注册客户端
 func NewClient(serverAddr string, opts ...Option) *Client {
 	if !strings.HasPrefix(serverAddr, "http://") && !strings.HasPrefix(serverAddr, "https://") {
@@ -75,7 +95,14 @@ func NewClient(serverAddr string, opts ...Option) *Client {
 
 // Register 注册MCP服务器到Nacos
 func (c *Client) Register(ctx context.Context, server types.ServerInterface) (string, error) {
-	if err := c.ensureAuth(); err != nil {
+	serverId, err := c.register(ctx, server)
+	c.metrics.ObserveRegistryOperation("register", err)
+	return serverId, err
+}
+
+// register 发送注册请求
+func (c *Client) register(ctx context.Context, server types.ServerInterface) (string, error) {
+	if err := c.ensureAuth(ctx); err != nil {
 		return "", fmt.Errorf("authentication failed: %w", err)
 	}
 
@@ -86,7 +113,7 @@ func (c *Client) Register(ctx context.Context, server types.ServerInterface) (st
 	}
 
 	// 发送请求
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("register request failed: %w", err)
 	}
@@ -98,7 +125,14 @@ func (c *Client) Register(ctx context.Context, server types.ServerInterface) (st
 
 // Deregister 注销MCP服务器
 func (c *Client) Deregister(ctx context.Context, serverId string) error {
-	if err := c.ensureAuth(); err != nil {
+	err := c.deregister(ctx, serverId)
+	c.metrics.ObserveRegistryOperation("deregister", err)
+	return err
+}
+
+// deregister 发送注销请求
+func (c *Client) deregister(ctx context.Context, serverId string) error {
+	if err := c.ensureAuth(ctx); err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
@@ -109,7 +143,7 @@ func (c *Client) Deregister(ctx context.Context, serverId string) error {
 	}
 
 	// 发送请求
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("deregister request failed: %w", err)
 	}
@@ -121,7 +155,7 @@ func (c *Client) Deregister(ctx context.Context, serverId string) error {
 
 // List 列出MCP服务器
 func (c *Client) List(ctx context.Context, search string, pageNo, pageSize int) (interface{}, error) {
-	if err := c.ensureAuth(); err != nil {
+	if err := c.ensureAuth(ctx); err != nil {
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	}
 
@@ -132,7 +166,7 @@ func (c *Client) List(ctx context.Context, search string, pageNo, pageSize int)
 	}
 
 	// 发送请求
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list request failed: %w", err)
 	}
@@ -143,7 +177,7 @@ func (c *Client) List(ctx context.Context, search string, pageNo, pageSize int)
 }
 
 // ensureAuth 确保认证
-func (c *Client) ensureAuth() error {
+func (c *Client) ensureAuth(ctx context.Context) error {
 	if c.username == "" || c.password == "" {
 		return nil // 无需认证
 	}
@@ -152,18 +186,24 @@ func (c *Client) ensureAuth() error {
 		return nil // 已认证
 	}
 
-	return c.login()
+	return c.login(ctx)
 }
 
 // login 登录获取访问令牌
-func (c *Client) login() error {
+func (c *Client) login(ctx context.Context) error {
 	loginURL := fmt.Sprintf("%s/nacos/v1/auth/login", c.serverAddr)
 
 	data := url.Values{}
 	data.Set("username", c.username)
 	data.Set("password", c.password)
 
-	resp, err := c.httpClient.PostForm(loginURL, data)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("create login request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("login request failed: %w", err)
 	}
@@ -204,6 +244,11 @@ func (c *Client) buildRegisterRequest(server types.ServerInterface) (*http.Reque
 		"enabled": true,
 	}
 
+	// 在服务器规范中声明认证方式，认证作用于整个MCP接口，客户端据此在调用时携带凭证
+	if schemes := server.GetSecuritySchemes(); len(schemes) > 0 {
+		serverSpec["securitySchemes"] = schemes
+	}
+
 	// 根据协议类型添加不同的配置
 	if server.GetProtocol() == types.ProtocolStdio {
 		// stdio协议使用本地配置
@@ -232,8 +277,17 @@ func (c *Client) buildRegisterRequest(server types.ServerInterface) (*http.Reque
 			"description": tool.Description,
 			"inputSchema": tool.InputSchema,
 		}
+		if tool.Title != "" {
+			mcpTools[i]["title"] = tool.Title
+		}
+		if tool.OutputSchema != nil {
+			mcpTools[i]["outputSchema"] = tool.OutputSchema
+		}
+		if tool.Annotations != nil {
+			mcpTools[i]["annotations"] = tool.Annotations
+		}
 
-		toolsMeta[tool.Name] = map[string]interface{}{
+		meta := map[string]interface{}{
 			"invokeContext": map[string]interface{}{
 				"path":   "/mcp",
 				"method": "POST",
@@ -257,6 +311,11 @@ func (c *Client) buildRegisterRequest(server types.ServerInterface) (*http.Reque
 				},
 			},
 		}
+		// 行为提示同步到 toolsMeta，便于在控制台中治理
+		if tool.Annotations != nil {
+			meta["annotations"] = tool.Annotations
+		}
+		toolsMeta[tool.Name] = meta
 	}
 
 	toolSpec := map[string]interface{}{
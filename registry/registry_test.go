@@ -0,0 +1,162 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"nacos-mcp-go/types"
+)
+
+// testServer 实现 types.ServerInterface 的测试服务器
+type testServer struct {
+	schemes []types.SecurityScheme
+	tools   []types.Tool
+}
+
+func (s *testServer) GetName() string                            { return "users" }
+func (s *testServer) GetNamespace() string                       { return "" }
+func (s *testServer) GetGroup() string                           { return "DEFAULT_GROUP" }
+func (s *testServer) GetAddress() (string, int)                  { return "127.0.0.1", 8080 }
+func (s *testServer) GetProtocol() types.Protocol                { return types.ProtocolStreamHTTP }
+func (s *testServer) GetMetadata() map[string]string             { return nil }
+func (s *testServer) IsRunning() bool                            { return true }
+func (s *testServer) GetSecuritySchemes() []types.SecurityScheme { return s.schemes }
+func (s *testServer) GetTools() []types.Tool {
+	if s.tools != nil {
+		return s.tools
+	}
+	return []types.Tool{{Name: "get_user", Description: "Get a user", InputSchema: map[string]interface{}{"type": "object"}}}
+}
+
+// registerSpecs 构建注册请求并解码其中的服务器规范和工具规范
+func registerSpecs(t *testing.T, server types.ServerInterface) (serverSpec, toolSpec map[string]interface{}) {
+	t.Helper()
+	req, err := NewClient("http://127.0.0.1:8848").buildRegisterRequest(server)
+	if err != nil {
+		t.Fatalf("buildRegisterRequest() error = %v", err)
+	}
+	if err := req.ParseForm(); err != nil {
+		t.Fatalf("ParseForm() error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(req.PostForm.Get("serverSpecification")), &serverSpec); err != nil {
+		t.Fatalf("decode serverSpecification: %v", err)
+	}
+	if err := json.Unmarshal([]byte(req.PostForm.Get("toolSpecification")), &toolSpec); err != nil {
+		t.Fatalf("decode toolSpecification: %v", err)
+	}
+	return serverSpec, toolSpec
+}
+
+func TestRegisterRequestSecuritySchemes(t *testing.T) {
+	server := &testServer{schemes: []types.SecurityScheme{
+		{ID: "oauth2", Type: "oauth2", ResourceMetadata: "https://mcp.example.com/.well-known/oauth-protected-resource/mcp"},
+		{ID: "bearer", Type: "http", Scheme: "bearer"},
+	}}
+	serverSpec, toolSpec := registerSpecs(t, server)
+
+	schemes, ok := serverSpec["securitySchemes"].([]interface{})
+	if !ok || len(schemes) != 2 {
+		t.Fatalf("serverSpecification securitySchemes = %v, want 2 schemes", serverSpec["securitySchemes"])
+	}
+	if id := schemes[0].(map[string]interface{})["id"]; id != "oauth2" {
+		t.Errorf("first scheme id = %v, want oauth2", id)
+	}
+	if _, ok := toolSpec["securitySchemes"]; ok {
+		t.Error("toolSpecification declares securitySchemes, want them only in the server specification")
+	}
+}
+
+func TestRegisterRequestWithoutSecuritySchemes(t *testing.T) {
+	serverSpec, _ := registerSpecs(t, &testServer{})
+	if _, ok := serverSpec["securitySchemes"]; ok {
+		t.Error("serverSpecification declares securitySchemes for a server without authentication")
+	}
+}
+
+func TestRegisterRequestAnnotations(t *testing.T) {
+	readOnly := true
+	server := &testServer{tools: []types.Tool{
+		{
+			Name:        "get_user",
+			Title:       "Get user",
+			InputSchema: map[string]interface{}{"type": "object"},
+			Annotations: &types.ToolAnnotations{ReadOnlyHint: &readOnly},
+		},
+		{Name: "echo", InputSchema: map[string]interface{}{"type": "object"}},
+	}}
+	_, toolSpec := registerSpecs(t, server)
+
+	tools, _ := toolSpec["tools"].([]interface{})
+	if len(tools) != 2 {
+		t.Fatalf("tools = %v, want 2 tools", toolSpec["tools"])
+	}
+	want := map[string]interface{}{"readOnlyHint": true}
+	getUser := tools[0].(map[string]interface{})
+	if getUser["title"] != "Get user" || !reflect.DeepEqual(getUser["annotations"], want) {
+		t.Errorf("get_user = %v, want title and annotations %v", getUser, want)
+	}
+	if _, ok := tools[1].(map[string]interface{})["annotations"]; ok {
+		t.Error("echo declares annotations, want none")
+	}
+
+	meta, _ := toolSpec["toolsMeta"].(map[string]interface{})
+	if got := meta["get_user"].(map[string]interface{})["annotations"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("toolsMeta get_user annotations = %v, want %v", got, want)
+	}
+	if _, ok := meta["echo"].(map[string]interface{})["annotations"]; ok {
+		t.Error("toolsMeta echo declares annotations, want none")
+	}
+}
+
+func TestClientSpan(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"pageItems":[]}`))
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "register")
+	if _, err := NewClient(ts.URL, WithTracerProvider(tp)).List(ctx, "", 1, 10); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	parent.End()
+
+	var span sdktrace.ReadOnlySpan
+	for _, ended := range recorder.Ended() {
+		if ended.SpanKind() == trace.SpanKindClient {
+			span = ended
+		}
+	}
+	if span == nil {
+		t.Fatalf("no client span among %d ended spans", len(recorder.Ended()))
+	}
+	if span.Name() != http.MethodGet || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q parent = %s, want GET under %s", span.Name(), span.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	if attrs["http.request.method"] != http.MethodGet || attrs["http.response.status_code"] != "200" {
+		t.Errorf("attributes = %v, want GET with status 200", attrs)
+	}
+	// 下游请求携带客户端span的链路上下文
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+}
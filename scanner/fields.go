@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Field 参与JSON编解码的结构体字段，解析规则与 encoding/json 一致
+type Field struct {
+	Name      string            // JSON字段名
+	GoName    string            // Go字段名
+	Index     []int             // 字段索引路径，嵌入结构体中的字段包含多级索引
+	Type      reflect.Type      // 字段类型
+	Tag       reflect.StructTag // 字段tag
+	OmitEmpty bool              // 是否带有 omitempty 选项
+
+	tagged bool // 字段名是否来自json tag
+}
+
+// fieldCache 结构体字段缓存 map[reflect.Type][]Field
+var fieldCache sync.Map
+
+// StructFields 返回结构体参与JSON编解码的字段，按字段声明顺序排列
+// 匿名嵌入的结构体字段会被展开，同名字段按 encoding/json 的规则取舍
+func StructFields(t reflect.Type) []Field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]Field)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]Field)
+}
+
+// typeFields 按广度优先遍历结构体及其嵌入结构体，收集所有字段
+func typeFields(t reflect.Type) []Field {
+	type entry struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	current := []entry{}
+	next := []entry{{typ: t}}
+
+	// 同一层级中每种嵌入类型出现的次数，出现多次的类型其字段会互相抵消
+	count := map[reflect.Type]int{}
+	nextCount := map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+
+	var fields []Field
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					// 未导出的非结构体嵌入字段会被忽略
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				jsonTag := sf.Tag.Get("json")
+				if jsonTag == "-" {
+					continue
+				}
+				name, omitEmpty := parseJSONTag(jsonTag)
+				if !isValidJSONName(name) {
+					name = ""
+				}
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// 普通字段或带名称的嵌入字段直接记录
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name
+					}
+					fields = append(fields, Field{
+						Name:      name,
+						GoName:    sf.Name,
+						Index:     index,
+						Type:      sf.Type,
+						Tag:       sf.Tag,
+						OmitEmpty: omitEmpty,
+						tagged:    tagged,
+					})
+					if count[e.typ] > 1 {
+						// 同层级出现多次的嵌入类型，重复记录以便后续按冲突处理
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				// 无名称的嵌入结构体，在下一层级展开
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, entry{typ: ft, index: index})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		if x[i].Name != x[j].Name {
+			return x[i].Name < x[j].Name
+		}
+		if len(x[i].Index) != len(x[j].Index) {
+			return len(x[i].Index) < len(x[j].Index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return lessIndex(x[i].Index, x[j].Index)
+	})
+
+	// 同名字段只保留层级最浅的一个，层级相同时优先取带json tag的字段，仍无法区分则全部丢弃
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		name := fields[i].Name
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].Name != name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fields[i])
+			continue
+		}
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].Index, fields[j].Index)
+	})
+
+	return fields
+}
+
+// dominantField 从同名字段中选出生效的字段，字段已按层级和tag排序
+func dominantField(fields []Field) (Field, bool) {
+	if len(fields) > 1 && len(fields[0].Index) == len(fields[1].Index) && fields[0].tagged == fields[1].tagged {
+		return Field{}, false
+	}
+	return fields[0], true
+}
+
+// lessIndex 比较两个字段索引路径的先后顺序
+func lessIndex(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// parseJSONTag 解析json tag，返回字段名和是否带有 omitempty 选项
+func parseJSONTag(tag string) (name string, omitEmpty bool) {
+	name, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+// isValidJSONName 检查json tag中的字段名是否合法
+func isValidJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// JSONSchemaProvider 由类型自身提供JSON Schema，适用于金额、ID、整数枚举等领域类型
+type JSONSchemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+// DecodeFunc 将JSON编码的参数值解码为目标类型的值
+type DecodeFunc func(data json.RawMessage) (interface{}, error)
+
+var jsonSchemaProviderType = reflect.TypeOf((*JSONSchemaProvider)(nil)).Elem()
+
+// TypeRegistry 类型注册表，为无法修改源码的第三方类型指定schema和解码器
+type TypeRegistry struct {
+	mu       sync.RWMutex
+	schemas  map[reflect.Type]map[string]interface{}
+	decoders map[reflect.Type]DecodeFunc
+	unions   map[reflect.Type]*Union
+}
+
+// NewTypeRegistry 创建类型注册表
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		schemas:  make(map[reflect.Type]map[string]interface{}),
+		decoders: make(map[reflect.Type]DecodeFunc),
+		unions:   make(map[reflect.Type]*Union),
+	}
+}
+
+// RegisterSchema 为类型指定JSON Schema
+func (r *TypeRegistry) RegisterSchema(t reflect.Type, schema map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[t] = schema
+}
+
+// RegisterDecoder 为类型指定解码器
+func (r *TypeRegistry) RegisterDecoder(t reflect.Type, decode DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[t] = decode
+}
+
+// Schema 获取类型注册的schema副本
+func (r *TypeRegistry) Schema(t reflect.Type) (map[string]interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	schema, ok := r.schemas[t]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return copySchema(schema), true
+}
+
+// Decoder 获取类型注册的解码器
+func (r *TypeRegistry) Decoder(t reflect.Type) (DecodeFunc, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	decode, ok := r.decoders[t]
+	return decode, ok
+}
+
+// hasCustomSchema 检查类型是否通过注册表或 JSONSchemaProvider 自定义了schema
+func (r *TypeRegistry) hasCustomSchema(t reflect.Type) bool {
+	if _, ok := r.Schema(t); ok {
+		return true
+	}
+	return t.Kind() != reflect.Interface && implements(t, jsonSchemaProviderType)
+}
+
+// providedSchema 调用类型的 JSONSchema 方法获取schema
+func providedSchema(t reflect.Type) (map[string]interface{}, bool) {
+	if t.Kind() == reflect.Interface {
+		return nil, false
+	}
+
+	var provider JSONSchemaProvider
+	switch {
+	case t.Implements(jsonSchemaProviderType):
+		provider = reflect.Zero(t).Interface().(JSONSchemaProvider)
+		if t.Kind() == reflect.Ptr {
+			provider = reflect.New(t.Elem()).Interface().(JSONSchemaProvider)
+		}
+	case reflect.PointerTo(t).Implements(jsonSchemaProviderType):
+		provider = reflect.New(t).Interface().(JSONSchemaProvider)
+	default:
+		return nil, false
+	}
+
+	schema := provider.JSONSchema()
+	if schema == nil {
+		return nil, false
+	}
+	return copySchema(schema), true
+}
+
+// copySchema 深拷贝schema，避免字段tag修改共享的schema
+func copySchema(schema map[string]interface{}) map[string]interface{} {
+	return CopySchemaValue(schema).(map[string]interface{})
+}
+
+// CopySchemaValue 深拷贝schema中的值，对象和数组逐层复制，其他值直接返回
+func CopySchemaValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = CopySchemaValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = CopySchemaValue(item)
+		}
+		return result
+	case []string:
+		return append([]string(nil), v...)
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// amountSchema 由 amount 直接返回的共享schema
+var amountSchema = map[string]interface{}{"type": "string", "pattern": `^\d+\.\d{2}$`}
+
+// amount 以值接收者提供schema的金额类型
+type amount int64
+
+func (amount) JSONSchema() map[string]interface{} { return amountSchema }
+
+// orderID 以指针接收者提供schema的ID类型
+type orderID string
+
+func (*orderID) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "uuid"}
+}
+
+// vendorID 模拟无法修改源码的第三方类型
+type vendorID struct{ value int }
+
+// order 字段使用了自定义schema的类型
+type order struct {
+	ID     orderID  `json:"id"`
+	Total  amount   `json:"total" mcp:"desc=Order total"`
+	Vendor vendorID `json:"vendor" mcp:"desc=Vendor of the order"`
+}
+
+func TestTypeSchemaJSONSchemaProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want map[string]interface{}
+	}{
+		{"value receiver", reflect.TypeOf(amount(0)), amountSchema},
+		{"pointer receiver on value type", reflect.TypeOf(orderID("")), map[string]interface{}{"type": "string", "format": "uuid"}},
+		{"pointer type", reflect.TypeOf((*orderID)(nil)), map[string]interface{}{"type": "string", "format": "uuid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := TypeSchema(tt.typ)
+			if err != nil {
+				t.Fatalf("TypeSchema() error = %v", err)
+			}
+			if !reflect.DeepEqual(schema, tt.want) {
+				t.Errorf("schema = %v, want %v", schema, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeSchemaRegisteredSchema(t *testing.T) {
+	registry := NewTypeRegistry()
+	registry.RegisterSchema(reflect.TypeOf(vendorID{}), map[string]interface{}{"type": "integer", "minimum": 1})
+	// 注册表中的schema优先于类型自身提供的schema
+	registry.RegisterSchema(reflect.TypeOf(amount(0)), map[string]interface{}{"type": "integer"})
+
+	schema, err := TypeSchema(reflect.TypeOf(order{}), WithTypeRegistry(registry))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+
+	if got, want := schemaAt(t, schema, "properties", "vendor"), map[string]interface{}{
+		"type": "integer", "minimum": 1, "description": "Vendor of the order",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vendor schema = %v, want %v", got, want)
+	}
+	if got := schemaAt(t, schema, "properties", "total")["type"]; got != "integer" {
+		t.Errorf("total type = %v, want integer", got)
+	}
+
+	// 字段tag只修改生成的副本
+	registered, _ := registry.Schema(reflect.TypeOf(vendorID{}))
+	if _, ok := registered["description"]; ok {
+		t.Errorf("registered schema was modified: %v", registered)
+	}
+}
+
+func TestTypeSchemaProviderSchemaIsCopied(t *testing.T) {
+	schema, err := TypeSchema(reflect.TypeOf(order{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+	if got := schemaAt(t, schema, "properties", "total")["description"]; got != "Order total" {
+		t.Errorf("total description = %v, want Order total", got)
+	}
+	if _, ok := amountSchema["description"]; ok {
+		t.Errorf("provided schema was modified: %v", amountSchema)
+	}
+}
+
+func TestTypeRegistrySchemaReturnsCopy(t *testing.T) {
+	registry := NewTypeRegistry()
+	typ := reflect.TypeOf(vendorID{})
+	registry.RegisterSchema(typ, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "integer"},
+		},
+		"required": []interface{}{"id"},
+	})
+
+	schema, _ := registry.Schema(typ)
+	schema["properties"].(map[string]interface{})["id"].(map[string]interface{})["type"] = "string"
+	schema["required"].([]interface{})[0] = "name"
+
+	again, _ := registry.Schema(typ)
+	if got := again["properties"].(map[string]interface{})["id"].(map[string]interface{})["type"]; got != "integer" {
+		t.Errorf("nested property type = %v, want integer", got)
+	}
+	if got := again["required"].([]interface{})[0]; got != "id" {
+		t.Errorf("required[0] = %v, want id", got)
+	}
+
+	var nilRegistry *TypeRegistry
+	if _, ok := nilRegistry.Schema(typ); ok {
+		t.Error("nil registry returned a schema")
+	}
+	if _, ok := nilRegistry.Decoder(typ); ok {
+		t.Error("nil registry returned a decoder")
+	}
+}
+
+func TestIsStructArgumentCustomSchema(t *testing.T) {
+	registry := NewTypeRegistry()
+	registry.RegisterSchema(reflect.TypeOf(vendorID{}), map[string]interface{}{"type": "integer"})
+	registry.RegisterDecoder(reflect.TypeOf(vendorID{}), func(data json.RawMessage) (interface{}, error) {
+		return vendorID{}, nil
+	})
+
+	if !IsStructArgument(reflect.TypeOf(func(order) {}), registry) {
+		t.Error("order should be expanded as the arguments object")
+	}
+	// 自定义了schema的结构体作为普通参数，不展开为参数对象
+	if IsStructArgument(reflect.TypeOf(func(vendorID) {}), registry) {
+		t.Error("vendorID with registered schema should not be expanded")
+	}
+	if !IsStructArgument(reflect.TypeOf(func(vendorID) {}), nil) {
+		t.Error("vendorID without registry should be expanded")
+	}
+}
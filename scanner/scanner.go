@@ -1,51 +1,132 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+
+	"nacos-mcp-go/ratelimit"
+	"nacos-mcp-go/types"
 )
 
+// MaxToolNameLength 工具名最大长度
+const MaxToolNameLength = 64
+
+// toolNamePattern MCP工具名允许的字符集
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// anonymousFuncPattern 匿名函数在运行时的名称片段，如 func1、glob..func2、func1.3
+var anonymousFuncPattern = regexp.MustCompile(`^(func)?\d+$`)
+
 // ToolInfo 工具信息
 type ToolInfo struct {
-	Name        string
-	Description string
-	InputSchema map[string]interface{}
-	Handler     interface{}
+	Name           string
+	Title          string
+	Description    string
+	InputSchema    map[string]interface{}
+	Handler        interface{}
+	ParamNames     []string               // 函数各参数在schema中的名称，单个结构体参数时为空
+	Annotations    *types.ToolAnnotations // 工具行为提示，未在tag中声明时为nil
+	OutputFormat   types.OutputFormat     // 返回值的文本格式，未在tag中声明时为空
+	Auth           *types.AuthRule        // 访问规则，未在tag中声明时为nil
+	Timeout        time.Duration          // 调用超时，未在tag中声明时为0
+	MaxConcurrency int                    // 最大并发调用数，未在tag中声明时为0
+	RateLimit      *types.RateLimit       // 所有调用方共享的限流，未在tag中声明时为nil
+	CacheTTL       time.Duration          // 结果缓存时长，未在tag中声明时为0
+}
+
+// Option 扫描选项
+type Option func(*options)
+
+// options 扫描配置
+type options struct {
+	registry *TypeRegistry
+}
+
+// WithTypeRegistry 设置类型注册表，用于为第三方类型指定schema
+func WithTypeRegistry(registry *TypeRegistry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// newOptions 应用扫描选项
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ValidateToolName 校验工具名是否符合MCP规范
+// 工具名长度为1-64，且只能包含字母、数字、下划线、中划线和点
+func ValidateToolName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tool name is empty")
+	}
+	if len(name) > MaxToolNameLength {
+		return fmt.Errorf("tool name %q exceeds %d characters", name, MaxToolNameLength)
+	}
+	if !toolNamePattern.MatchString(name) {
+		return fmt.Errorf("tool name %q contains invalid characters, only [a-zA-Z0-9_.-] are allowed", name)
+	}
+	return nil
 }
 
 // ScanTool 扫描函数并解析MCP工具信息
-func ScanTool(handler interface{}) (*ToolInfo, error) {
+// 具名函数以 snake_case 形式的函数名作为默认工具名，匿名函数的工具名为空，需由调用方指定
+func ScanTool(handler interface{}, opts ...Option) (*ToolInfo, error) {
+	o := newOptions(opts)
 	handlerValue := reflect.ValueOf(handler)
 	handlerType := reflect.TypeOf(handler)
 
-	if handlerType.Kind() != reflect.Func {
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
 		return nil, fmt.Errorf("handler must be a function")
 	}
 
 	// 解析函数名作为默认工具名
 	funcName := getFunctionName(handlerValue)
-	toolName := strings.ToLower(funcName)
+	toolName := ToSnakeCase(funcName)
+
+	description := fmt.Sprintf("Auto-generated tool for %s", funcName)
+	if funcName == "" {
+		description = "Auto-generated tool for anonymous function"
+	}
 
 	// 解析函数参数，构建输入schema
-	inputSchema, err := buildInputSchema(handlerType)
+	inputSchema, paramNames, err := buildInputSchema(handlerType, nil, o.registry)
 	if err != nil {
 		return nil, fmt.Errorf("build input schema failed: %w", err)
 	}
 
 	return &ToolInfo{
 		Name:        toolName,
-		Description: fmt.Sprintf("Auto-generated tool for %s", funcName),
+		Description: description,
 		InputSchema: inputSchema,
 		Handler:     handler,
+		ParamNames:  paramNames,
 	}, nil
 }
 
 // ScanStruct 扫描结构体字段并解析MCP工具信息
 // 支持形如: GetTime func() string `mcp:"tool;name=get_current_time;description=获取服务器当前时间"`
-func ScanStruct(obj interface{}) ([]*ToolInfo, error) {
+func ScanStruct(obj interface{}, opts ...Option) ([]*ToolInfo, error) {
+	o := newOptions(opts)
 	objValue := reflect.ValueOf(obj)
 	objType := reflect.TypeOf(obj)
+	if objType == nil {
+		return nil, fmt.Errorf("object must be a struct or pointer to struct")
+	}
+
+	// 保留原始值用于扫描方法，指针接收者的方法只存在于指针的方法集中
+	methodValue, methodType := objValue, objType
 
 	if objType.Kind() == reflect.Ptr {
 		objValue = objValue.Elem()
@@ -57,279 +138,295 @@ func ScanStruct(obj interface{}) ([]*ToolInfo, error) {
 	}
 
 	var tools []*ToolInfo
+	var errs []error
 
-	// 遍历结构体字段
+	// 遍历结构体字段，函数字段的 mcp tag 为 "-" 时忽略
 	for i := 0; i < objType.NumField(); i++ {
 		field := objType.Field(i)
 		fieldValue := objValue.Field(i)
 
-		// 检查字段是否是函数类型且有mcp tag
-		if field.Type.Kind() == reflect.Func {
-			mcpTag := field.Tag.Get("mcp")
-			if mcpTag != "" && strings.Contains(mcpTag, "tool") {
-				if tool, err := parseFieldAsTool(fieldValue.Interface(), field, mcpTag); err == nil {
-					tools = append(tools, tool)
-				}
-			}
+		mcpTag := field.Tag.Get("mcp")
+		if field.Type.Kind() != reflect.Func || mcpTag == "" || mcpTag == "-" {
+			continue
 		}
+		if !field.IsExported() {
+			errs = append(errs, fmt.Errorf("field %s: unexported field cannot be a tool", field.Name))
+			continue
+		}
+
+		if fieldValue.IsNil() {
+			errs = append(errs, fmt.Errorf("field %s: function is nil", field.Name))
+			continue
+		}
+
+		tool, err := parseFieldAsTool(fieldValue.Interface(), field, mcpTag, o.registry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", field.Name, err))
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	// 如果没有找到函数字段，则尝试扫描方法（向后兼容）
 	if len(tools) == 0 {
-		return scanStructMethods(objValue, objType)
+		return scanStructMethods(methodValue, methodType, o.registry)
 	}
 
 	return tools, nil
 }
 
 // scanStructMethods 扫描结构体方法（向后兼容）
-func scanStructMethods(objValue reflect.Value, objType reflect.Type) ([]*ToolInfo, error) {
+func scanStructMethods(objValue reflect.Value, objType reflect.Type, registry *TypeRegistry) ([]*ToolInfo, error) {
 	var tools []*ToolInfo
+	var errs []error
 
 	// 遍历结构体方法
 	for i := 0; i < objValue.NumMethod(); i++ {
 		method := objValue.Method(i)
 		methodType := objType.Method(i)
 
-		// 检查是否有mcp tag
-		if tool, err := parseMethodAsTool(method.Interface(), methodType); err == nil {
-			tools = append(tools, tool)
+		tool, err := parseMethodAsTool(method.Interface(), methodType, registry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("method %s: %w", methodType.Name, err))
+			continue
 		}
+		tools = append(tools, tool)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	return tools, nil
 }
 
 // parseMethodAsTool 解析方法为MCP工具
-func parseMethodAsTool(method interface{}, methodType reflect.Method) (*ToolInfo, error) {
+func parseMethodAsTool(method interface{}, methodType reflect.Method, registry *TypeRegistry) (*ToolInfo, error) {
 	// 检查方法是否导出
 	if !methodType.IsExported() {
 		return nil, fmt.Errorf("method %s is not exported", methodType.Name)
 	}
 
-	// 解析方法签名
-	funcType := methodType.Type
-	inputSchema, err := buildInputSchema(funcType)
+	// 解析方法签名，使用绑定了接收者的方法值类型，不包含receiver参数
+	funcType := reflect.TypeOf(method)
+	inputSchema, paramNames, err := buildInputSchema(funcType, nil, registry)
 	if err != nil {
-		return nil, fmt.Errorf("build input schema for method %s failed: %w", methodType.Name, err)
+		return nil, fmt.Errorf("build input schema failed: %w", err)
 	}
 
 	return &ToolInfo{
-		Name:        strings.ToLower(methodType.Name),
+		Name:        ToSnakeCase(methodType.Name),
 		Description: fmt.Sprintf("Auto-generated tool for method %s", methodType.Name),
 		InputSchema: inputSchema,
 		Handler:     method,
+		ParamNames:  paramNames,
 	}, nil
 }
 
-// buildInputSchema 构建输入schema
-func buildInputSchema(funcType reflect.Type) (map[string]interface{}, error) {
-	properties := make(map[string]interface{})
-	required := []string{}
-
-	// 跳过receiver参数（如果是方法）
-	startIdx := 0
-	if funcType.NumIn() > 0 {
-		// 检查第一个参数是否是receiver
-		firstParam := funcType.In(0)
-		if firstParam.Kind() == reflect.Ptr || firstParam.Kind() == reflect.Struct {
-			startIdx = 1
-		}
-	}
-
-	// 解析函数参数
-	for i := startIdx; i < funcType.NumIn(); i++ {
-		paramType := funcType.In(i)
-		paramName := fmt.Sprintf("param%d", i-startIdx+1)
-
-		// 解析参数类型为JSON Schema
-		paramSchema, err := typeToJSONSchema(paramType)
-		if err != nil {
-			return nil, fmt.Errorf("convert parameter %d to JSON schema failed: %w", i, err)
-		}
-
-		properties[paramName] = paramSchema
-		required = append(required, paramName)
+// parseFieldAsTool 解析函数字段为MCP工具
+func parseFieldAsTool(fn interface{}, field reflect.StructField, mcpTag string, registry *TypeRegistry) (*ToolInfo, error) {
+	// 解析mcp tag
+	tag, err := parseMcpTag(mcpTag)
+	if err != nil {
+		return nil, fmt.Errorf("parse mcp tag failed: %w", err)
 	}
 
-	schema := map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
+	// 如果没有指定工具名，使用字段名
+	toolName := tag.name
+	if toolName == "" {
+		toolName = ToSnakeCase(field.Name)
 	}
 
-	if len(required) > 0 {
-		schema["required"] = required
+	// 构建输入schema
+	inputSchema, paramNames, err := buildInputSchema(field.Type, tag.paramNames, registry)
+	if err != nil {
+		return nil, fmt.Errorf("build input schema failed: %w", err)
 	}
 
-	return schema, nil
+	return &ToolInfo{
+		Name:           toolName,
+		Title:          tag.title,
+		Description:    tag.description,
+		InputSchema:    inputSchema,
+		Handler:        fn,
+		ParamNames:     paramNames,
+		Annotations:    tag.annotations,
+		OutputFormat:   tag.output,
+		Auth:           tag.auth,
+		Timeout:        tag.timeout,
+		MaxConcurrency: tag.concurrency,
+		RateLimit:      tag.rateLimit,
+		CacheTTL:       tag.cacheTTL,
+	}, nil
 }
 
-// typeToJSONSchema 将Go类型转换为JSON Schema
-func typeToJSONSchema(t reflect.Type) (map[string]interface{}, error) {
-	switch t.Kind() {
-	case reflect.String:
-		return map[string]interface{}{
-			"type":        "string",
-			"description": "String parameter",
-		}, nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return map[string]interface{}{
-			"type":        "integer",
-			"description": "Integer parameter",
-		}, nil
-	case reflect.Float32, reflect.Float64:
-		return map[string]interface{}{
-			"type":        "number",
-			"description": "Number parameter",
-		}, nil
-	case reflect.Bool:
-		return map[string]interface{}{
-			"type":        "boolean",
-			"description": "Boolean parameter",
-		}, nil
-	case reflect.Slice, reflect.Array:
-		elemSchema, err := typeToJSONSchema(t.Elem())
-		if err != nil {
-			return nil, err
-		}
-		return map[string]interface{}{
-			"type":        "array",
-			"items":       elemSchema,
-			"description": "Array parameter",
-		}, nil
-	case reflect.Struct:
-		return parseStructToSchema(t)
-	case reflect.Ptr:
-		return typeToJSONSchema(t.Elem())
-	default:
-		return map[string]interface{}{
-			"type":        "object",
-			"description": "Complex parameter",
-		}, nil
-	}
+// toolTag 函数字段上 mcp tag 声明的工具信息
+type toolTag struct {
+	name        string
+	title       string
+	description string
+	paramNames  []string
+	annotations *types.ToolAnnotations
+	output      types.OutputFormat
+	auth        *types.AuthRule
+	timeout     time.Duration
+	concurrency int
+	rateLimit   *types.RateLimit
+	cacheTTL    time.Duration
 }
 
-// parseStructToSchema 解析结构体为JSON Schema
-func parseStructToSchema(t reflect.Type) (map[string]interface{}, error) {
-	properties := make(map[string]interface{})
-	required := []string{}
-
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if !field.IsExported() {
-			continue
-		}
+// toolTagKeys 函数字段mcp tag支持的键，值为该键是否需要取值
+var toolTagKeys = map[string]bool{
+	"tool":        false,
+	"name":        true,
+	"title":       true,
+	"description": true,
+	"paramNames":  true,
+	"output":      true,
+	"readonly":    false,
+	"destructive": false,
+	"idempotent":  false,
+	"openworld":   false,
+	"principals":  true,
+	"scopes":      true,
+	"timeout":     true,
+	"concurrency": true,
+	"ratelimit":   true,
+	"cache":       true,
+}
 
-		fieldName := field.Name
-		jsonTag := field.Tag.Get("json")
-		mcpTag := field.Tag.Get("mcp")
+// parseMcpTag 解析mcp tag
+// 格式: "tool;name=get_current_time;title=当前时间;description=获取服务器当前时间;paramNames=keyword,limit;readonly;output=markdown;timeout=5s;concurrency=4;ratelimit=100/m;cache=30s"
+// 行为提示 readonly、destructive、idempotent、openworld 单独出现时为true，也可写作 readonly=false
+// 值中包含分隔符时使用单引号或反斜杠转义，如 description='查询; 支持分页'
+func parseMcpTag(tag string) (*toolTag, error) {
+	entries, err := parseTagEntries(tag, ';', toolTagKeys)
+	if err != nil {
+		return nil, err
+	}
 
-		// 解析json tag
-		if jsonTag != "" && jsonTag != "-" {
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] != "" {
-				fieldName = parts[0]
+	result := &toolTag{}
+	isTool := false
+	for _, entry := range entries {
+		switch entry.key {
+		case "tool":
+			isTool = true
+		case "name":
+			result.name = entry.value
+		case "title":
+			result.title = entry.value
+		case "description":
+			result.description = entry.value
+		case "output":
+			result.output = types.OutputFormat(strings.TrimSpace(entry.value))
+		case "paramNames":
+			names, err := splitTagValue(entry.raw, ',', ';')
+			if err != nil {
+				return nil, fmt.Errorf("invalid paramNames %q: %w", entry.raw, err)
 			}
-		}
-
-		// 解析字段类型
-		fieldSchema, err := typeToJSONSchema(field.Type)
-		if err != nil {
-			return nil, fmt.Errorf("parse field %s failed: %w", field.Name, err)
-		}
-
-		// 解析mcp tag中的描述信息
-		if mcpTag != "" {
-			parts := strings.Split(mcpTag, ",")
-			for _, part := range parts {
-				if strings.HasPrefix(part, "desc=") {
-					fieldSchema["description"] = strings.TrimPrefix(part, "desc=")
-				}
-				if part == "required" {
-					required = append(required, fieldName)
+			result.paramNames = names
+		case "principals", "scopes":
+			values, err := splitTagValue(entry.raw, ',', ';')
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", entry.key, entry.raw, err)
+			}
+			if result.auth == nil {
+				result.auth = &types.AuthRule{}
+			}
+			if entry.key == "principals" {
+				result.auth.Principals = values
+			} else {
+				result.auth.Scopes = values
+			}
+		case "timeout", "cache":
+			d, err := time.ParseDuration(strings.TrimSpace(entry.value))
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid %s %q: must be a positive duration such as 30s", entry.key, entry.value)
+			}
+			if entry.key == "timeout" {
+				result.timeout = d
+			} else {
+				result.cacheTTL = d
+			}
+		case "concurrency":
+			n, err := strconv.Atoi(strings.TrimSpace(entry.value))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid concurrency %q: must be a positive integer", entry.value)
+			}
+			result.concurrency = n
+		case "ratelimit":
+			limit, err := ratelimit.Parse(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			result.rateLimit = &limit
+		case "readonly", "destructive", "idempotent", "openworld":
+			hint := true
+			if entry.hasValue {
+				b, err := strconv.ParseBool(strings.TrimSpace(entry.value))
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s value %q: %w", entry.key, entry.value, err)
 				}
+				hint = b
 			}
+			result.setHint(entry.key, hint)
 		}
-
-		properties[fieldName] = fieldSchema
-	}
-
-	schema := map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
 	}
 
-	if len(required) > 0 {
-		schema["required"] = required
+	if !isTool {
+		return nil, fmt.Errorf("missing tool key")
 	}
-
-	return schema, nil
+	return result, nil
 }
 
-// parseFieldAsTool 解析函数字段为MCP工具
-func parseFieldAsTool(fn interface{}, field reflect.StructField, mcpTag string) (*ToolInfo, error) {
-	// 解析mcp tag
-	toolName, description, paramNames, err := parseMcpTag(mcpTag)
-	if err != nil {
-		return nil, fmt.Errorf("parse mcp tag failed: %w", err)
+// setHint 设置工具行为提示
+func (t *toolTag) setHint(key string, hint bool) {
+	if t.annotations == nil {
+		t.annotations = &types.ToolAnnotations{}
 	}
-
-	// 如果没有指定工具名，使用字段名
-	if toolName == "" {
-		toolName = strings.ToLower(field.Name)
+	switch key {
+	case "readonly":
+		t.annotations.ReadOnlyHint = &hint
+	case "destructive":
+		t.annotations.DestructiveHint = &hint
+	case "idempotent":
+		t.annotations.IdempotentHint = &hint
+	case "openworld":
+		t.annotations.OpenWorldHint = &hint
 	}
+}
 
-	// 构建输入schema
-	inputSchema, err := buildFunctionInputSchema(field.Type, paramNames)
+// buildInputSchema 构建函数输入schema，并返回各参数在schema中的名称
+// context.Context 参数不作为工具参数；函数只有一个结构体参数时，结构体字段直接作为工具参数；否则按 paramNames 或 param1、param2... 命名参数
+// 指针类型的参数为可选参数，其余参数为必填参数
+func buildInputSchema(funcType reflect.Type, paramNames []string, registry *TypeRegistry) (map[string]interface{}, []string, error) {
+	sig, err := ParseSignature(funcType)
 	if err != nil {
-		return nil, fmt.Errorf("build input schema failed: %w", err)
+		return nil, nil, err
 	}
+	gen := newSchemaGenerator(registry)
 
-	return &ToolInfo{
-		Name:        toolName,
-		Description: description,
-		InputSchema: inputSchema,
-		Handler:     fn,
-	}, nil
-}
-
-// parseMcpTag 解析mcp tag
-// 格式: "tool;name=get_current_time;description=获取服务器当前时间;paramNames=keyword,limit"
-func parseMcpTag(tag string) (name, description string, paramNames []string, err error) {
-	parts := strings.Split(tag, ";")
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "tool" {
-			continue
+	if IsStructArgument(funcType, registry) {
+		structType := sig.Params[0]
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
 		}
-
-		if strings.HasPrefix(part, "name=") {
-			name = strings.TrimPrefix(part, "name=")
-		} else if strings.HasPrefix(part, "description=") {
-			description = strings.TrimPrefix(part, "description=")
-		} else if strings.HasPrefix(part, "paramNames=") {
-			paramNamesStr := strings.TrimPrefix(part, "paramNames=")
-			if paramNamesStr != "" {
-				paramNames = strings.Split(paramNamesStr, ",")
-				for i, pname := range paramNames {
-					paramNames[i] = strings.TrimSpace(pname)
-				}
-			}
+		schema, err := gen.structSchema(structType, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("convert parameter 0 to JSON schema failed: %w", err)
 		}
+		gen.attachDefs(schema)
+		return schema, nil, nil
 	}
 
-	return name, description, paramNames, nil
-}
-
-// buildFunctionInputSchema 构建函数输入schema
-func buildFunctionInputSchema(funcType reflect.Type, paramNames []string) (map[string]interface{}, error) {
 	properties := make(map[string]interface{})
 	required := []string{}
+	names := make([]string, len(sig.Params))
 
 	// 解析函数参数
-	for i := 0; i < funcType.NumIn(); i++ {
-		paramType := funcType.In(i)
+	for i, paramType := range sig.Params {
 
 		// 确定参数名
 		var paramName string
@@ -338,35 +435,84 @@ func buildFunctionInputSchema(funcType reflect.Type, paramNames []string) (map[s
 		} else {
 			paramName = fmt.Sprintf("param%d", i+1)
 		}
+		if _, exists := properties[paramName]; exists {
+			return nil, nil, fmt.Errorf("duplicate parameter name %q", paramName)
+		}
+		names[i] = paramName
 
 		// 解析参数类型为JSON Schema
-		paramSchema, err := typeToJSONSchema(paramType)
+		paramSchema, err := gen.typeToJSONSchema(paramType)
 		if err != nil {
-			return nil, fmt.Errorf("convert parameter %d to JSON schema failed: %w", i, err)
+			return nil, nil, fmt.Errorf("convert parameter %d to JSON schema failed: %w", i, err)
 		}
 
 		properties[paramName] = paramSchema
-		required = append(required, paramName)
+		if paramType.Kind() != reflect.Ptr {
+			required = append(required, paramName)
+		}
 	}
 
 	schema := map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
 	}
 
 	if len(required) > 0 {
 		schema["required"] = required
 	}
+	gen.attachDefs(schema)
 
-	return schema, nil
+	return schema, names, nil
 }
 
-// getFunctionName 获取函数名
+// getFunctionName 获取函数名，匿名函数返回空字符串
 func getFunctionName(fn reflect.Value) string {
-	fullName := fn.Type().String()
-	if strings.Contains(fullName, ".") {
-		parts := strings.Split(fullName, ".")
-		return parts[len(parts)-1]
+	runtimeFunc := runtime.FuncForPC(fn.Pointer())
+	if runtimeFunc == nil {
+		return ""
+	}
+
+	// 运行时名称形如 example.com/pkg.(*Type).Method-fm 或 main.main.func1
+	fullName := runtimeFunc.Name()
+	if idx := strings.LastIndex(fullName, "/"); idx >= 0 {
+		fullName = fullName[idx+1:]
+	}
+	fullName = strings.TrimSuffix(fullName, "-fm")
+	if idx := strings.Index(fullName, "["); idx >= 0 {
+		fullName = fullName[:idx]
+	}
+
+	parts := strings.Split(fullName, ".")
+	if len(parts) < 2 {
+		return ""
 	}
-	return fullName
+	for _, part := range parts[1:] {
+		if anonymousFuncPattern.MatchString(part) {
+			return ""
+		}
+	}
+	return parts[len(parts)-1]
+}
+
+// ToSnakeCase 将驼峰命名转换为 snake_case，如 GetHTTPServer 转换为 get_http_server
+func ToSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
 }
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateToolName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"get_user", false},
+		{"get-user", false},
+		{"user.get", false},
+		{"v2.users.list_all", false},
+		{"A1", false},
+		{strings.Repeat("a", MaxToolNameLength), false},
+		{"", true},
+		{strings.Repeat("a", MaxToolNameLength+1), true},
+		{"get user", true},
+		{"get/user", true},
+		{"用户", true},
+		{"int) []int", true},
+	}
+	for _, tt := range tests {
+		err := ValidateToolName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateToolName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"GetUser", "get_user"},
+		{"getUser", "get_user"},
+		{"HTTPServer", "http_server"},
+		{"GetHTTPServer", "get_http_server"},
+		{"userID", "user_id"},
+		{"ParseJSONValue", "parse_json_value"},
+		{"V2Users", "v2_users"},
+		{"get_user", "get_user"},
+		{"Get_User", "get_user"},
+		{"ID", "id"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ToSnakeCase(tt.in); got != tt.want {
+			t.Errorf("ToSnakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// nameService 方法值命名的测试服务
+type nameService struct{}
+
+func (nameService) ListUsers() []string     { return nil }
+func (*nameService) GetUserByID(id int) int { return id }
+
+func GetHTTPStatus() int { return 200 }
+
+func genericName[T any](v T) T { return v }
+
+func TestGetFunctionName(t *testing.T) {
+	service := &nameService{}
+	tests := []struct {
+		name string
+		fn   interface{}
+		want string
+	}{
+		{"function", GetHTTPStatus, "GetHTTPStatus"},
+		{"value method value", nameService{}.ListUsers, "ListUsers"},
+		{"pointer method value", service.GetUserByID, "GetUserByID"},
+		{"method expression", (*nameService).GetUserByID, "GetUserByID"},
+		{"generic function", genericName[int], "genericName"},
+		{"anonymous function", func() {}, ""},
+		{"closure", func() func() int { return func() int { return 1 } }(), ""},
+	}
+	for _, tt := range tests {
+		if got := getFunctionName(reflect.ValueOf(tt.fn)); got != tt.want {
+			t.Errorf("%s: getFunctionName() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScanToolDerivesSnakeCaseName(t *testing.T) {
+	service := &nameService{}
+	for _, tt := range []struct {
+		fn   interface{}
+		want string
+	}{
+		{GetHTTPStatus, "get_http_status"},
+		{service.GetUserByID, "get_user_by_id"},
+	} {
+		info, err := ScanTool(tt.fn)
+		if err != nil {
+			t.Fatalf("ScanTool() error = %v", err)
+		}
+		if info.Name != tt.want {
+			t.Errorf("ScanTool() name = %q, want %q", info.Name, tt.want)
+		}
+	}
+}
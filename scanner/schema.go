@@ -0,0 +1,392 @@
+package scanner
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// defNamePattern $defs 名称中不允许出现的字符，泛型实例化类型名会包含这些字符
+var defNamePattern = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// schemaGenerator 将Go类型转换为JSON Schema
+// 自引用的结构体类型会被收集到 $defs 中，并通过 $ref 引用
+type schemaGenerator struct {
+	registry  *TypeRegistry
+	defs      map[string]interface{}
+	defNames  map[reflect.Type]string
+	takenDefs map[string]bool
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+}
+
+// newSchemaGenerator 创建schema生成器，每个工具的schema使用独立的生成器
+func newSchemaGenerator(registry *TypeRegistry) *schemaGenerator {
+	return &schemaGenerator{
+		registry:  registry,
+		defs:      make(map[string]interface{}),
+		defNames:  make(map[reflect.Type]string),
+		takenDefs: make(map[string]bool),
+		visiting:  make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+	}
+}
+
+// attachDefs 将收集到的 $defs 附加到根schema
+func (g *schemaGenerator) attachDefs(schema map[string]interface{}) {
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+}
+
+// IsStructArgument 判断函数是否只有一个结构体参数（不计 context.Context 参数）
+// 此时结构体字段直接作为工具参数，调用时整个参数对象解码为该结构体
+// 自定义了schema的结构体类型仍作为普通参数处理
+func IsStructArgument(funcType reflect.Type, registry *TypeRegistry) bool {
+	sig, err := ParseSignature(funcType)
+	if err != nil || len(sig.Params) != 1 {
+		return false
+	}
+	return isObjectStruct(sig.Params[0], registry)
+}
+
+// isObjectStruct 判断类型是否为按字段展开的结构体或结构体指针
+func isObjectStruct(t reflect.Type, registry *TypeRegistry) bool {
+	if t.Kind() == reflect.Ptr {
+		if registry.hasCustomSchema(t) {
+			return false
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || registry.hasCustomSchema(t) {
+		return false
+	}
+	return !customJSON(t) && !customText(t)
+}
+
+// TypeSchema 生成Go类型的完整JSON Schema，递归类型的定义附加在根schema的 $defs 中
+// 结构体字段直接展开在根schema上，与单个结构体参数的工具输入schema一致
+func TypeSchema(t reflect.Type, opts ...Option) (map[string]interface{}, error) {
+	o := newOptions(opts)
+	gen := newSchemaGenerator(o.registry)
+
+	var schema map[string]interface{}
+	var err error
+	if isObjectStruct(t, o.registry) {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		schema, err = gen.structSchema(t, true)
+	} else {
+		schema, err = gen.typeToJSONSchema(t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	gen.attachDefs(schema)
+	return schema, nil
+}
+
+// typeToJSONSchema 将Go类型转换为JSON Schema
+func (g *schemaGenerator) typeToJSONSchema(t reflect.Type) (map[string]interface{}, error) {
+	// 注册表中的schema优先，其次是类型自身提供的schema
+	if schema, ok := g.registry.Schema(t); ok {
+		return schema, nil
+	}
+	if schema, ok := providedSchema(t); ok {
+		return schema, nil
+	}
+	if union, ok := g.registry.Union(t); ok {
+		return g.unionSchema(t, union)
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{
+			"type":        "string",
+			"format":      "date-time",
+			"description": "Date-time in RFC 3339 format",
+		}, nil
+	case durationType:
+		return map[string]interface{}{
+			"type":        "string",
+			"format":      "duration",
+			"description": "Duration such as 1h30m, 45s or 500ms",
+		}, nil
+	}
+
+	// 自定义了JSON编解码的类型无法从结构推断schema，自定义了文本编解码的类型编码为字符串
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		if customJSON(t) {
+			return map[string]interface{}{
+				"description": fmt.Sprintf("JSON value of %s", t),
+			}, nil
+		}
+		if customText(t) {
+			return map[string]interface{}{
+				"type":        "string",
+				"description": "String parameter",
+			}, nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{
+			"type":        "string",
+			"description": "String parameter",
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema := map[string]interface{}{
+			"type":        "integer",
+			"description": "Integer parameter",
+		}
+		// 定长整数声明取值范围，避免调用时溢出
+		if bits := t.Bits(); bits < 64 {
+			schema["minimum"] = -(int64(1) << (bits - 1))
+			schema["maximum"] = int64(1)<<(bits-1) - 1
+		}
+		return schema, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		schema := map[string]interface{}{
+			"type":        "integer",
+			"minimum":     0,
+			"description": "Non-negative integer parameter",
+		}
+		if bits := t.Bits(); bits < 64 {
+			schema["maximum"] = uint64(1)<<bits - 1
+		}
+		return schema, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{
+			"type":        "number",
+			"description": "Number parameter",
+		}, nil
+	case reflect.Bool:
+		return map[string]interface{}{
+			"type":        "boolean",
+			"description": "Boolean parameter",
+		}, nil
+	case reflect.Slice:
+		// []byte 与 encoding/json 一致编码为base64字符串
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{
+				"type":            "string",
+				"contentEncoding": "base64",
+				"description":     "Base64 encoded bytes",
+			}, nil
+		}
+		return g.arraySchema(t)
+	case reflect.Array:
+		schema, err := g.arraySchema(t)
+		if err != nil {
+			return nil, err
+		}
+		schema["minItems"] = t.Len()
+		schema["maxItems"] = t.Len()
+		return schema, nil
+	case reflect.Map:
+		return g.mapSchema(t)
+	case reflect.Struct:
+		return g.structSchema(t, false)
+	case reflect.Ptr:
+		return g.typeToJSONSchema(t.Elem())
+	case reflect.Interface:
+		return map[string]interface{}{
+			"description": "Any JSON value",
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// implements 检查类型或其指针是否实现了接口
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// customJSON 判断类型是否自定义了JSON编码或解码，调用时按 json.Unmarshaler 解码
+func customJSON(t reflect.Type) bool {
+	return implements(t, jsonMarshalerType) || implements(t, jsonUnmarshalerType)
+}
+
+// customText 判断类型是否自定义了文本编码或解码，调用时按 encoding.TextUnmarshaler 解码
+func customText(t reflect.Type) bool {
+	return implements(t, textMarshalerType) || implements(t, textUnmarshalerType)
+}
+
+// arraySchema 构建数组schema
+func (g *schemaGenerator) arraySchema(t reflect.Type) (map[string]interface{}, error) {
+	elemSchema, err := g.typeToJSONSchema(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       elemSchema,
+		"description": "Array parameter",
+	}, nil
+}
+
+// mapSchema 构建map的schema，键类型需满足 encoding/json 的要求
+func (g *schemaGenerator) mapSchema(t reflect.Type) (map[string]interface{}, error) {
+	valueSchema, err := g.typeToJSONSchema(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": valueSchema,
+		"description":          "Map parameter",
+	}
+
+	key := t.Key()
+	switch key.Kind() {
+	case reflect.String:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["propertyNames"] = map[string]interface{}{"pattern": "^-?[0-9]+$"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		schema["propertyNames"] = map[string]interface{}{"pattern": "^[0-9]+$"}
+	default:
+		if !customText(key) {
+			return nil, fmt.Errorf("unsupported map key type %s", key)
+		}
+	}
+
+	return schema, nil
+}
+
+// structSchema 构建结构体schema
+// 递归引用自身的具名结构体放入 $defs，inline 为 true 时仍返回完整schema（用于根schema）
+func (g *schemaGenerator) structSchema(t reflect.Type, inline bool) (map[string]interface{}, error) {
+	if t.Name() == "" {
+		return g.parseStructToSchema(t)
+	}
+
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return g.ref(t), nil
+	}
+	if name, ok := g.defNames[t]; ok && !inline {
+		if _, exists := g.defs[name]; exists {
+			return g.ref(t), nil
+		}
+	}
+
+	g.visiting[t] = true
+	schema, err := g.parseStructToSchema(t)
+	delete(g.visiting, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.recursive[t] {
+		return schema, nil
+	}
+
+	if inline {
+		// 根schema还会附加 $defs，这里存放浅拷贝以避免循环引用
+		def := make(map[string]interface{}, len(schema))
+		for k, v := range schema {
+			def[k] = v
+		}
+		g.defs[g.defName(t)] = def
+		return schema, nil
+	}
+	g.defs[g.defName(t)] = schema
+	return g.ref(t), nil
+}
+
+// parseStructToSchema 解析结构体为JSON Schema，嵌入结构体的字段按 encoding/json 规则展开
+func (g *schemaGenerator) parseStructToSchema(t reflect.Type) (map[string]interface{}, error) {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for _, field := range StructFields(t) {
+		// 解析字段类型
+		fieldSchema, err := g.typeToJSONSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parse field %s failed: %w", field.GoName, err)
+		}
+
+		// 解析mcp tag中的描述信息
+		req := requirementInferred
+		if mcpTag := field.Tag.Get("mcp"); mcpTag != "" {
+			req, err = applyFieldTag(fieldSchema, mcpTag)
+			if err != nil {
+				return nil, fmt.Errorf("parse mcp tag of field %s failed: %w", field.GoName, err)
+			}
+		}
+		if isRequiredField(field, fieldSchema, req) {
+			required = append(required, field.Name)
+		}
+
+		properties[field.Name] = fieldSchema
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema, nil
+}
+
+// isRequiredField 判断结构体字段是否必填
+// tag中的 required/optional 优先，否则指针、omitempty 或带默认值的字段为可选字段
+func isRequiredField(field Field, schema map[string]interface{}, req requirement) bool {
+	switch req {
+	case requirementRequired:
+		return true
+	case requirementOptional:
+		return false
+	}
+
+	if field.Type.Kind() == reflect.Ptr || field.OmitEmpty {
+		return false
+	}
+	_, hasDefault := schema["default"]
+	return !hasDefault
+}
+
+// ref 返回指向 $defs 中类型定义的引用
+func (g *schemaGenerator) ref(t reflect.Type) map[string]interface{} {
+	return map[string]interface{}{
+		"$ref": "#/$defs/" + g.defName(t),
+	}
+}
+
+// defName 返回类型在 $defs 中的名称，不同包中的同名类型追加序号区分
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+
+	base := defNamePattern.ReplaceAllString(t.Name(), "_")
+	name := base
+	for i := 2; g.takenDefs[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	g.defNames[t] = name
+	g.takenDefs[name] = true
+	return name
+}
@@ -0,0 +1,306 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jsonValue 只实现了值接收者的 UnmarshalJSON
+type jsonValue struct{ raw string }
+
+func (jsonValue) UnmarshalJSON([]byte) error { return nil }
+
+// jsonPointer 只实现了指针接收者的 UnmarshalJSON
+type jsonPointer struct{ raw string }
+
+func (*jsonPointer) UnmarshalJSON([]byte) error { return nil }
+
+// textValue 只实现了值接收者的 UnmarshalText
+type textValue struct{ raw string }
+
+func (textValue) UnmarshalText([]byte) error { return nil }
+
+// textPointer 只实现了指针接收者的 UnmarshalText
+type textPointer struct{ raw string }
+
+func (t *textPointer) UnmarshalText(b []byte) error {
+	t.raw = strings.ToUpper(string(b))
+	return nil
+}
+
+func TestTypeSchemaUnmarshalers(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		wantType interface{} // nil 表示不限制类型
+	}{
+		{"json value receiver", reflect.TypeOf(jsonValue{}), nil},
+		{"json pointer receiver", reflect.TypeOf(jsonPointer{}), nil},
+		{"text value receiver", reflect.TypeOf(textValue{}), "string"},
+		{"text pointer receiver", reflect.TypeOf(textPointer{}), "string"},
+		{"pointer to json type", reflect.TypeOf(&jsonPointer{}), nil},
+		{"pointer to text type", reflect.TypeOf(&textPointer{}), "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := TypeSchema(tt.typ)
+			if err != nil {
+				t.Fatalf("TypeSchema() error = %v", err)
+			}
+			if schema["type"] != tt.wantType {
+				t.Errorf("type = %v, want %v (schema %v)", schema["type"], tt.wantType, schema)
+			}
+			if _, ok := schema["properties"]; ok {
+				t.Errorf("schema has properties, want opaque schema: %v", schema)
+			}
+		})
+	}
+}
+
+func TestIsStructArgumentUnmarshalers(t *testing.T) {
+	funcs := map[string]interface{}{
+		"json value receiver":   func(context.Context, jsonValue) error { return nil },
+		"json pointer receiver": func(context.Context, jsonPointer) error { return nil },
+		"text value receiver":   func(context.Context, textValue) error { return nil },
+		"text pointer receiver": func(context.Context, *textPointer) error { return nil },
+	}
+	for name, fn := range funcs {
+		if IsStructArgument(reflect.TypeOf(fn), nil) {
+			t.Errorf("%s: IsStructArgument() = true, want false", name)
+		}
+	}
+
+	plain := func(context.Context, struct{ Name string }) error { return nil }
+	if !IsStructArgument(reflect.TypeOf(plain), nil) {
+		t.Error("plain struct: IsStructArgument() = false, want true")
+	}
+}
+
+func TestFieldSchemaUnmarshalers(t *testing.T) {
+	type request struct {
+		JSON jsonPointer            `json:"json"`
+		Text textPointer            `json:"text"`
+		Keys map[textPointer]string `json:"keys"`
+	}
+	schema, err := TypeSchema(reflect.TypeOf(request{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	if got := properties["json"].(map[string]interface{})["type"]; got != nil {
+		t.Errorf("json field type = %v, want unrestricted", got)
+	}
+	if got := properties["text"].(map[string]interface{})["type"]; got != "string" {
+		t.Errorf("text field type = %v, want string", got)
+	}
+	if got := properties["keys"].(map[string]interface{})["type"]; got != "object" {
+		t.Errorf("keys field type = %v, want object", got)
+	}
+}
+
+// treeNode 引用自身的结构体
+type treeNode struct {
+	Name     string      `json:"name"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// forest 通过字段引用递归结构体
+type forest struct {
+	Root  *treeNode `json:"root"`
+	Other treeNode  `json:"other"`
+}
+
+// point map值的结构体
+type point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// schemaAt 按属性路径读取子schema
+func schemaAt(t *testing.T, schema map[string]interface{}, path ...string) map[string]interface{} {
+	t.Helper()
+	for _, key := range path {
+		next, ok := schema[key].(map[string]interface{})
+		if !ok {
+			t.Fatalf("schema has no object at %q: %v", key, schema)
+		}
+		schema = next
+	}
+	return schema
+}
+
+func TestTypeSchemaSelfReferencingStruct(t *testing.T) {
+	schema, err := TypeSchema(reflect.TypeOf(treeNode{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+
+	if schema["type"] != "object" {
+		t.Fatalf("root type = %v, want the struct inlined at the root", schema["type"])
+	}
+	items := schemaAt(t, schema, "properties", "children", "items")
+	if items["$ref"] != "#/$defs/treeNode" {
+		t.Errorf("children items = %v, want $ref to #/$defs/treeNode", items)
+	}
+	def := schemaAt(t, schema, "$defs", "treeNode")
+	if _, ok := schemaAt(t, def, "properties")["children"]; !ok {
+		t.Errorf("$defs/treeNode = %v, want the full struct schema", def)
+	}
+	if _, ok := def["$defs"]; ok {
+		t.Error("$defs/treeNode contains $defs, want them only at the root")
+	}
+}
+
+func TestTypeSchemaRecursiveStructField(t *testing.T) {
+	schema, err := TypeSchema(reflect.TypeOf(forest{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+
+	for _, field := range []string{"root", "other"} {
+		if ref := schemaAt(t, schema, "properties", field)["$ref"]; ref != "#/$defs/treeNode" {
+			t.Errorf("%s = %v, want $ref to #/$defs/treeNode", field, ref)
+		}
+	}
+	defs := schemaAt(t, schema, "$defs")
+	if len(defs) != 1 {
+		t.Errorf("$defs = %v, want only treeNode", defs)
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		t.Errorf("schema cannot be encoded: %v", err)
+	}
+}
+
+func TestTypeSchemaMaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        reflect.Type
+		valueType  interface{}
+		keyPattern interface{}
+		wantErr    bool
+	}{
+		{name: "string to struct", typ: reflect.TypeOf(map[string]point{}), valueType: "object"},
+		{name: "string to slice", typ: reflect.TypeOf(map[string][]int{}), valueType: "array"},
+		{name: "int keys", typ: reflect.TypeOf(map[int]string{}), valueType: "string", keyPattern: "^-?[0-9]+$"},
+		{name: "uint keys", typ: reflect.TypeOf(map[uint16]bool{}), valueType: "boolean", keyPattern: "^[0-9]+$"},
+		{name: "text keys", typ: reflect.TypeOf(map[textValue]float64{}), valueType: "number"},
+		{name: "struct keys", typ: reflect.TypeOf(map[point]string{}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := TypeSchema(tt.typ)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("TypeSchema() = %v, want error", schema)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TypeSchema() error = %v", err)
+			}
+			if schema["type"] != "object" {
+				t.Errorf("type = %v, want object", schema["type"])
+			}
+			if got := schemaAt(t, schema, "additionalProperties")["type"]; got != tt.valueType {
+				t.Errorf("additionalProperties type = %v, want %v", got, tt.valueType)
+			}
+			names, _ := schema["propertyNames"].(map[string]interface{})
+			if got := names["pattern"]; got != tt.keyPattern {
+				t.Errorf("propertyNames pattern = %v, want %v", got, tt.keyPattern)
+			}
+		})
+	}
+
+	schema, err := TypeSchema(reflect.TypeOf(map[string]point{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := schemaAt(t, schema, "additionalProperties", "properties", "x"); x["type"] != "integer" {
+		t.Errorf("map value field x = %v, want integer", x)
+	}
+}
+
+func TestTypeSchemaWellKnownTypes(t *testing.T) {
+	tests := []struct {
+		typ  reflect.Type
+		key  string
+		want interface{}
+	}{
+		{reflect.TypeOf(time.Time{}), "format", "date-time"},
+		{reflect.TypeOf(time.Duration(0)), "format", "duration"},
+		{reflect.TypeOf([]byte(nil)), "contentEncoding", "base64"},
+		{reflect.TypeOf([3]int{}), "maxItems", 3},
+		{reflect.TypeOf(int8(0)), "maximum", int64(127)},
+		{reflect.TypeOf(uint8(0)), "maximum", uint64(255)},
+	}
+	for _, tt := range tests {
+		schema, err := TypeSchema(tt.typ)
+		if err != nil {
+			t.Fatalf("TypeSchema(%s) error = %v", tt.typ, err)
+		}
+		if schema[tt.key] != tt.want {
+			t.Errorf("TypeSchema(%s)[%s] = %v (%T), want %v", tt.typ, tt.key, schema[tt.key], schema[tt.key], tt.want)
+		}
+	}
+}
+
+// optionalFields 覆盖各种可选性规则的结构体
+type optionalFields struct {
+	Name     string            `json:"name"`
+	Nickname *string           `json:"nickname"`
+	Age      int               `json:"age,omitempty"`
+	Role     string            `json:"role" mcp:"default=guest"`
+	Tags     []string          `json:"tags" mcp:"optional"`
+	Token    *string           `json:"token" mcp:"required"`
+	Labels   map[string]string `json:"labels" mcp:"default={\"env\":\"dev\"}"`
+	Internal string            `json:"-"`
+	hidden   string
+}
+
+func TestTypeSchemaOptionalFields(t *testing.T) {
+	schema, err := TypeSchema(reflect.TypeOf(optionalFields{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+
+	required := map[string]bool{}
+	for _, name := range schema["required"].([]string) {
+		required[name] = true
+	}
+	want := map[string]bool{
+		"name":     true,  // 非指针且没有 omitempty
+		"nickname": false, // 指针
+		"age":      false, // omitempty
+		"role":     false, // 有默认值
+		"tags":     false, // mcp:"optional"
+		"token":    true,  // mcp:"required" 优先于指针
+		"labels":   false,
+	}
+	for name, wantRequired := range want {
+		if required[name] != wantRequired {
+			t.Errorf("%s required = %v, want %v", name, required[name], wantRequired)
+		}
+	}
+
+	properties := schemaAt(t, schema, "properties")
+	for _, name := range []string{"Internal", "-", "hidden"} {
+		if _, ok := properties[name]; ok {
+			t.Errorf("properties contain %s, want json:\"-\" and unexported fields skipped", name)
+		}
+	}
+	if len(properties) != len(want) {
+		t.Errorf("properties = %v, want %d fields", properties, len(want))
+	}
+
+	if def := schemaAt(t, properties, "role")["default"]; def != "guest" {
+		t.Errorf("role default = %v, want guest", def)
+	}
+	labels := schemaAt(t, properties, "labels", "default")
+	if labels["env"] != "dev" {
+		t.Errorf("labels default = %v, want {env: dev}", labels)
+	}
+}
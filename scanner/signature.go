@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// StreamKind 工具函数流式返回值的类型
+type StreamKind int
+
+const (
+	StreamNone StreamKind = iota // 非流式返回值
+	StreamChan                   // <-chan T 或 chan T
+	StreamSeq                    // iter.Seq[T]
+	StreamSeq2                   // iter.Seq2[T, error]
+)
+
+// Signature 工具函数签名
+type Signature struct {
+	Context bool           // 第一个参数为 context.Context，调用时传入请求的上下文
+	Params  []reflect.Type // 工具参数类型，不包含 context.Context 参数
+	Result  reflect.Type   // 返回值类型，无返回值或只返回 error 时为nil
+	Error   bool           // 最后一个返回值为 error
+	Stream  StreamKind     // 返回值的流式类型
+	Elem    reflect.Type   // 流式返回值的元素类型
+}
+
+// ParseSignature 解析工具函数签名
+// 支持的返回值: 无、T、error、(T, error)；T 为 <-chan E、iter.Seq[E]、iter.Seq2[E, error] 时按流式结果处理
+func ParseSignature(funcType reflect.Type) (*Signature, error) {
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("handler must be a function")
+	}
+	if funcType.IsVariadic() {
+		return nil, fmt.Errorf("variadic function is not supported")
+	}
+
+	sig := &Signature{}
+	for i := 0; i < funcType.NumIn(); i++ {
+		paramType := funcType.In(i)
+		if paramType == contextType {
+			if i != 0 {
+				return nil, fmt.Errorf("context.Context must be the first parameter")
+			}
+			sig.Context = true
+			continue
+		}
+		sig.Params = append(sig.Params, paramType)
+	}
+
+	switch funcType.NumOut() {
+	case 0:
+	case 1:
+		if funcType.Out(0) == errorType {
+			sig.Error = true
+		} else {
+			sig.Result = funcType.Out(0)
+		}
+	case 2:
+		if funcType.Out(1) != errorType {
+			return nil, fmt.Errorf("second return value must be error, got %s", funcType.Out(1))
+		}
+		sig.Result = funcType.Out(0)
+		sig.Error = true
+	default:
+		return nil, fmt.Errorf("function returns %d values, at most (result, error) is supported", funcType.NumOut())
+	}
+
+	if sig.Result != nil {
+		kind, elem, err := streamKind(sig.Result)
+		if err != nil {
+			return nil, err
+		}
+		sig.Stream, sig.Elem = kind, elem
+	}
+	return sig, nil
+}
+
+// streamKind 判断返回值是否为通道或迭代器
+func streamKind(t reflect.Type) (StreamKind, reflect.Type, error) {
+	switch t.Kind() {
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir == 0 {
+			return StreamNone, nil, fmt.Errorf("send-only channel %s cannot be returned", t)
+		}
+		return StreamChan, t.Elem(), nil
+	case reflect.Func:
+		// iter.Seq[E] 为 func(yield func(E) bool)，iter.Seq2[E, error] 为 func(yield func(E, error) bool)
+		if t.NumIn() == 1 && t.NumOut() == 0 {
+			yield := t.In(0)
+			if yield.Kind() == reflect.Func && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool {
+				switch {
+				case yield.NumIn() == 1:
+					return StreamSeq, yield.In(0), nil
+				case yield.NumIn() == 2 && yield.In(1) == errorType:
+					return StreamSeq2, yield.In(0), nil
+				}
+			}
+		}
+		return StreamNone, nil, fmt.Errorf("unsupported return type %s, only iter.Seq[T] and iter.Seq2[T, error] functions are supported", t)
+	}
+	return StreamNone, nil, nil
+}
@@ -0,0 +1,332 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// requirement 字段在mcp tag中声明的必填性
+type requirement int
+
+const (
+	requirementInferred requirement = iota // 未声明，按字段类型推断
+	requirementRequired                    // 声明为 required
+	requirementOptional                    // 声明为 optional
+)
+
+// fieldTagKeys 结构体字段mcp tag支持的键，值为该键是否需要取值
+var fieldTagKeys = map[string]bool{
+	"desc":       true,
+	"required":   false,
+	"optional":   false,
+	"deprecated": false,
+	"sensitive":  false,
+	"format":     true,
+	"pattern":    true,
+	"minimum":    true,
+	"maximum":    true,
+	"minLength":  true,
+	"maxLength":  true,
+	"enum":       true,
+	"default":    true,
+	"example":    true,
+}
+
+// applyFieldTag 将结构体字段的mcp tag应用到字段schema，返回tag中声明的必填性
+// 格式: "desc=用户名,required,minLength=1,maxLength=32,enum=admin|guest,default=guest"
+// 值中包含分隔符时使用单引号或反斜杠转义，如 desc='姓, 名' 或 enum='a|b'|c
+// 反斜杠只转义引号、反斜杠和分隔符，pattern=^\d+$ 中的反斜杠原样保留
+func applyFieldTag(schema map[string]interface{}, tag string) (req requirement, err error) {
+	entries, err := parseTagEntries(tag, ',', fieldTagKeys)
+	if err != nil {
+		return requirementInferred, err
+	}
+
+	for _, entry := range entries {
+		key, value := entry.key, entry.value
+
+		switch key {
+		case "desc":
+			schema["description"] = value
+		case "required":
+			req = requirementRequired
+		case "optional":
+			req = requirementOptional
+		case "deprecated":
+			schema["deprecated"] = true
+		case "sensitive":
+			// 以 writeOnly 标记敏感字段，审计记录中该字段的值被脱敏
+			schema["writeOnly"] = true
+		case "format":
+			schema["format"] = strings.TrimSpace(value)
+		case "pattern":
+			if _, err := regexp.Compile(value); err != nil {
+				return requirementInferred, fmt.Errorf("invalid pattern %q: %w", value, err)
+			}
+			schema["pattern"] = value
+		case "minimum", "maximum":
+			if !hasSchemaType(schema, "integer", "number") {
+				return requirementInferred, fmt.Errorf("%s only applies to numeric fields", key)
+			}
+			n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return requirementInferred, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
+			schema[key] = n
+		case "minLength", "maxLength":
+			if !hasSchemaType(schema, "string") {
+				return requirementInferred, fmt.Errorf("%s only applies to string fields", key)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return requirementInferred, fmt.Errorf("invalid %s %q: must be a non-negative integer", key, value)
+			}
+			schema[key] = n
+		case "enum":
+			items, err := splitTagValue(entry.raw, '|', ',')
+			if err != nil {
+				return requirementInferred, fmt.Errorf("invalid enum %q: %w", entry.raw, err)
+			}
+			var values []interface{}
+			for _, item := range items {
+				v, err := parseTagValue(schema, item)
+				if err != nil {
+					return requirementInferred, fmt.Errorf("invalid enum value %q: %w", item, err)
+				}
+				values = append(values, v)
+			}
+			schema["enum"] = values
+		case "default":
+			v, err := parseTagValue(schema, value)
+			if err != nil {
+				return requirementInferred, fmt.Errorf("invalid default %q: %w", value, err)
+			}
+			schema["default"] = v
+		case "example":
+			v, err := parseTagValue(schema, value)
+			if err != nil {
+				return requirementInferred, fmt.Errorf("invalid example %q: %w", value, err)
+			}
+			schema["examples"] = []interface{}{v}
+		}
+	}
+
+	return req, nil
+}
+
+// tagEntry tag中的一个键值项
+type tagEntry struct {
+	key      string
+	raw      string // 未去除引号和转义的原始值，用于按二级分隔符继续拆分
+	value    string
+	hasValue bool
+}
+
+// tagKeyPattern tag键名格式
+var tagKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+
+// parseTagEntries 按分隔符解析tag为键值项
+// keys 为允许的键及其是否需要取值，未知键、重复键、缺少或多余的值都会返回错误
+func parseTagEntries(tag string, sep rune, keys map[string]bool) ([]tagEntry, error) {
+	parts, err := splitTag(tag, sep, string(sep))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]tagEntry, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, raw, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !tagKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid key %q", key)
+		}
+		needValue, known := keys[key]
+		if !known {
+			if suggestion := closestKey(key, keys); suggestion != "" {
+				return nil, fmt.Errorf("unknown key %q (did you mean %q?)", key, suggestion)
+			}
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		seen[key] = true
+		if needValue && !hasValue {
+			return nil, fmt.Errorf("key %q requires a value", key)
+		}
+
+		value, err := unquoteTagValue(raw, string(sep))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %w", key, err)
+		}
+		entries = append(entries, tagEntry{key: key, raw: raw, value: value, hasValue: hasValue})
+	}
+
+	return entries, nil
+}
+
+// isEscape 判断 runes[i] 是否为转义符
+// 只有位于引号、反斜杠或 specials 中的分隔符之前的反斜杠才是转义符，其余反斜杠原样保留，如正则 ^\d+$
+func isEscape(runes []rune, i int, specials string) bool {
+	if runes[i] != '\\' || i+1 >= len(runes) {
+		return false
+	}
+	next := runes[i+1]
+	return next == '\\' || next == '\'' || strings.ContainsRune(specials, next)
+}
+
+// splitTag 按未被引号包围且未转义的分隔符拆分tag，保留各段的引号和转义
+// specials 为当前及外层的分隔符，其前的反斜杠为转义符
+func splitTag(tag string, sep rune, specials string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	quoted := false
+
+	runes := []rune(tag)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isEscape(runes, i, specials):
+			current.WriteRune(r)
+			i++
+			r = runes[i]
+		case r == '\'':
+			quoted = !quoted
+		case r == sep && !quoted:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", tag)
+	}
+	return append(parts, current.String()), nil
+}
+
+// splitTagValue 按二级分隔符拆分原始值，并去除各项的引号和转义
+// outer 为原始值所在层级的分隔符，其转义在拆分后一并去除
+func splitTagValue(raw string, sep, outer rune) ([]string, error) {
+	specials := string([]rune{outer, sep})
+	parts, err := splitTag(raw, sep, specials)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		if values[i], err = unquoteTagValue(part, specials); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// unquoteTagValue 去除值两端的空白，并处理单引号和反斜杠转义
+// 引号内的空白原样保留，如 ' a ' 的值为 " a "；specials 之外的字符前的反斜杠原样保留
+func unquoteTagValue(raw string, specials string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	var b strings.Builder
+	b.Grow(len(raw))
+	quoted := false
+
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isEscape(runes, i, specials):
+			i++
+			b.WriteRune(runes[i])
+		case r == '\'':
+			quoted = !quoted
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return "", fmt.Errorf("unterminated quote in %q", raw)
+	}
+	return b.String(), nil
+}
+
+// closestKey 返回与未知键最接近的已知键，用于提示拼写错误
+func closestKey(key string, keys map[string]bool) string {
+	best, bestDistance := "", 3
+	for candidate := range keys {
+		d := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+		if d < bestDistance || (d == bestDistance && best != "" && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// hasSchemaType 检查schema的类型是否为给定类型之一
+func hasSchemaType(schema map[string]interface{}, types ...string) bool {
+	schemaType, _ := schema["type"].(string)
+	for _, t := range types {
+		if schemaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTagValue 按字段schema的类型解析tag中的值，复杂类型使用JSON格式
+func parseTagValue(schema map[string]interface{}, raw string) (interface{}, error) {
+	switch schema["type"] {
+	case "string":
+		return raw, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	case "number":
+		return strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	case "boolean":
+		return strconv.ParseBool(strings.TrimSpace(raw))
+	case "array", "object":
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		// 未声明类型的字段优先按JSON解析，失败时作为字符串
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return raw, nil
+		}
+		return v, nil
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"nacos-mcp-go/types"
+)
+
+func TestApplyFieldTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		key     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"plain description", `desc=user name`, "description", "user name", false},
+		{"quoted separator", `desc='Full name, as printed'`, "description", "Full name, as printed", false},
+		{"quoted whitespace", `desc=' padded '`, "description", " padded ", false},
+		{"escaped separator", `desc=Title\, optional`, "description", "Title, optional", false},
+		{"escaped quote", `desc=it\'s`, "description", "it's", false},
+		{"escaped backslash", `desc=a\\b`, "description", `a\b`, false},
+		{"backslash before other rune", `desc=C:\temp`, "description", `C:\temp`, false},
+		{"regex digit class", `pattern=^\d+$`, "pattern", `^\d+$`, false},
+		{"regex word and space", `pattern=^\w+\s\w+$`, "pattern", `^\w+\s\w+$`, false},
+		{"regex escaped dot", `pattern=^v\d+\.\d+$`, "pattern", `^v\d+\.\d+$`, false},
+		{"regex with escaped comma", `pattern=^\d{1\,3}$`, "pattern", `^\d{1,3}$`, false},
+		{"regex quoted", `pattern='^[a-z]{2,4}$'`, "pattern", `^[a-z]{2,4}$`, false},
+		{"trailing backslash kept", `desc=ends with\`, "description", `ends with\`, false},
+		{"enum", `enum=a|b|c`, "enum", []interface{}{"a", "b", "c"}, false},
+		{"enum quoted item", `enum='a|b'|c`, "enum", []interface{}{"a|b", "c"}, false},
+		{"enum escaped pipe", `enum=a\|b|c`, "enum", []interface{}{"a|b", "c"}, false},
+		{"enum escaped comma", `enum=a\,b|c`, "enum", []interface{}{"a,b", "c"}, false},
+		{"enum backslash kept", `enum=a\b|c`, "enum", []interface{}{`a\b`, "c"}, false},
+		{"unterminated quote", `desc='open`, "", nil, true},
+		{"unknown key", `descripton=typo`, "", nil, true},
+		{"duplicate key", `desc=a,desc=b`, "", nil, true},
+		{"missing value", `pattern`, "", nil, true},
+		{"invalid regex", `pattern=^(\d+$`, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := map[string]interface{}{"type": "string"}
+			_, err := applyFieldTag(schema, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyFieldTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := schema[tt.key]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyFieldTag(%q) %s = %#v, want %#v", tt.tag, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldTagPatternFromStructTag(t *testing.T) {
+	type request struct {
+		Code string `json:"code" mcp:"desc=numeric code,pattern=^\\d+$"`
+	}
+	schema, err := TypeSchema(reflect.TypeOf(request{}))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+	code := schema["properties"].(map[string]interface{})["code"].(map[string]interface{})
+	pattern, _ := code["pattern"].(string)
+	if pattern != `^\d+$` {
+		t.Fatalf("pattern = %q, want %q", pattern, `^\d+$`)
+	}
+	if re := regexp.MustCompile(pattern); !re.MatchString("123") || re.MatchString("d") {
+		t.Errorf("pattern %q does not match digits only", pattern)
+	}
+}
+
+func TestParseMcpTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		tag         string
+		description string
+		paramNames  []string
+		wantErr     bool
+	}{
+		{"plain", `tool;name=search;description=Search users`, "Search users", nil, false},
+		{"quoted separator", `tool;description='Search users; supports paging'`, "Search users; supports paging", nil, false},
+		{"escaped separator", `tool;description=Search users\; supports paging`, "Search users; supports paging", nil, false},
+		{"backslash kept", `tool;description=Match \d digits`, `Match \d digits`, nil, false},
+		{"param names", `tool;paramNames=keyword,limit`, "", []string{"keyword", "limit"}, false},
+		{"param names escaped", `tool;paramNames=a\,b,c`, "", []string{"a,b", "c"}, false},
+		{"unknown key", `tool;descripton=typo`, "", nil, true},
+		{"unterminated quote", `tool;description='open`, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMcpTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMcpTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.description != tt.description {
+				t.Errorf("description = %q, want %q", got.description, tt.description)
+			}
+			if !reflect.DeepEqual(got.paramNames, tt.paramNames) {
+				t.Errorf("paramNames = %#v, want %#v", got.paramNames, tt.paramNames)
+			}
+		})
+	}
+}
+
+func TestScanStructReportsFieldErrors(t *testing.T) {
+	type service struct {
+		Search func(string) []string `mcp:"tool;name=search;descripton=typo"`
+		Echo   func(string) string   `mcp:"tool;name=echo;description='open"`
+		Ok     func() string         `mcp:"tool;name=ok;description=fine"`
+	}
+	_, err := ScanStruct(&service{
+		Search: func(string) []string { return nil },
+		Echo:   func(s string) string { return s },
+		Ok:     func() string { return "" },
+	})
+	if err == nil {
+		t.Fatal("ScanStruct() error = nil, want errors for Search and Echo")
+	}
+	for _, want := range []string{"field Search", `did you mean "description"`, "field Echo", "unterminated quote"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ScanStruct() error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestParseMcpTagAnnotations(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		tag     string
+		want    *types.ToolAnnotations
+		wantErr bool
+	}{
+		{"none", `tool;title=Search users`, nil, false},
+		{"bare keys", `tool;readonly;openworld`, &types.ToolAnnotations{ReadOnlyHint: &yes, OpenWorldHint: &yes}, false},
+		{"explicit values", `tool;destructive=false;idempotent=true`, &types.ToolAnnotations{DestructiveHint: &no, IdempotentHint: &yes}, false},
+		{"invalid value", `tool;readonly=maybe`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMcpTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMcpTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.annotations, tt.want) {
+				t.Errorf("annotations = %+v, want %+v", got.annotations, tt.want)
+			}
+		})
+	}
+}
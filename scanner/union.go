@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Union 接口类型的多态定义，参数对象中判别属性的值决定解码为哪个具体类型
+type Union struct {
+	Discriminator string                  // 判别属性名，如 "type"
+	Variants      map[string]reflect.Type // 判别值到具体类型的映射，具体类型为结构体或结构体指针
+}
+
+// RegisterUnion 为接口类型注册具体实现，生成schema时输出 oneOf，调用时按判别属性解码为对应类型
+func (r *TypeRegistry) RegisterUnion(iface reflect.Type, discriminator string, variants map[string]reflect.Type) error {
+	if iface == nil || iface.Kind() != reflect.Interface {
+		return fmt.Errorf("union type %v must be an interface", iface)
+	}
+	if discriminator == "" {
+		return fmt.Errorf("union %s: discriminator is empty", iface)
+	}
+	if len(variants) == 0 {
+		return fmt.Errorf("union %s: no variants", iface)
+	}
+
+	copied := make(map[string]reflect.Type, len(variants))
+	for value, variant := range variants {
+		if value == "" {
+			return fmt.Errorf("union %s: discriminator value is empty", iface)
+		}
+		if variant == nil {
+			return fmt.Errorf("union %s: variant %q is nil", iface, value)
+		}
+		if !variant.Implements(iface) {
+			return fmt.Errorf("union %s: variant %q type %s does not implement the interface", iface, value, variant)
+		}
+		structType := variant
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return fmt.Errorf("union %s: variant %q type %s must be a struct or pointer to struct", iface, value, variant)
+		}
+		if field, ok := fieldByName(structType, discriminator); ok && field.Type.Kind() != reflect.String {
+			return fmt.Errorf("union %s: variant %q field %s must be a string to hold the discriminator", iface, value, field.GoName)
+		}
+		copied[value] = variant
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unions[iface] = &Union{Discriminator: discriminator, Variants: copied}
+	return nil
+}
+
+// Union 获取接口类型注册的多态定义
+func (r *TypeRegistry) Union(t reflect.Type) (*Union, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	union, ok := r.unions[t]
+	return union, ok
+}
+
+// Values 返回排序后的判别值
+func (u *Union) Values() []string {
+	values := make([]string, 0, len(u.Variants))
+	for value := range u.Variants {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// fieldByName 按JSON名称查找结构体字段
+func fieldByName(t reflect.Type, name string) (Field, bool) {
+	for _, field := range StructFields(t) {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// unionSchema 构建接口类型的 oneOf schema，每个变体都要求判别属性等于其判别值
+// 递归引用自身的接口类型放入 $defs
+func (g *schemaGenerator) unionSchema(t reflect.Type, union *Union) (map[string]interface{}, error) {
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return g.ref(t), nil
+	}
+	if name, ok := g.defNames[t]; ok {
+		if _, exists := g.defs[name]; exists {
+			return g.ref(t), nil
+		}
+	}
+
+	g.visiting[t] = true
+	schema, err := g.buildUnionSchema(union)
+	delete(g.visiting, t)
+	if err != nil {
+		return nil, fmt.Errorf("union %s: %w", t, err)
+	}
+
+	if !g.recursive[t] {
+		return schema, nil
+	}
+	g.defs[g.defName(t)] = schema
+	return g.ref(t), nil
+}
+
+// buildUnionSchema 按判别值顺序生成各变体的schema
+func (g *schemaGenerator) buildUnionSchema(union *Union) (map[string]interface{}, error) {
+	values := union.Values()
+	variants := make([]interface{}, 0, len(values))
+	names := make([]string, 0, len(values))
+
+	for _, value := range values {
+		structType := union.Variants[value]
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+
+		variant, err := g.parseStructToSchema(structType)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", value, err)
+		}
+
+		properties, _ := variant["properties"].(map[string]interface{})
+		discriminator := map[string]interface{}{
+			"type":  "string",
+			"const": value,
+		}
+		if existing, ok := properties[union.Discriminator].(map[string]interface{}); ok {
+			if desc, ok := existing["description"]; ok {
+				discriminator["description"] = desc
+			}
+		}
+		properties[union.Discriminator] = discriminator
+
+		required := toStringSlice(variant["required"])
+		if !containsString(required, union.Discriminator) {
+			variant["required"] = append(required, union.Discriminator)
+		}
+		if structType.Name() != "" {
+			variant["title"] = structType.Name()
+		}
+
+		variants = append(variants, variant)
+		names = append(names, fmt.Sprintf("%q", value))
+	}
+
+	return map[string]interface{}{
+		"oneOf": variants,
+		"discriminator": map[string]interface{}{
+			"propertyName": union.Discriminator,
+		},
+		"description": fmt.Sprintf("One of %s, selected by %q", strings.Join(names, ", "), union.Discriminator),
+	}, nil
+}
+
+// toStringSlice 读取schema中的字符串数组
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// containsString 检查字符串切片是否包含给定值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
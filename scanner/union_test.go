@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+// figure 注册为多态定义的接口
+type figure interface{ figure() }
+
+type square struct {
+	Side float64 `json:"side"`
+}
+
+func (square) figure() {}
+
+// labeled 声明了判别属性字段的变体
+type labeled struct {
+	Kind string `json:"kind" mcp:"desc=Figure kind"`
+	Text string `json:"text"`
+}
+
+func (*labeled) figure() {}
+
+// group 包含同一接口字段的变体，生成递归schema
+type group struct {
+	Items []figure `json:"items"`
+}
+
+func (group) figure() {}
+
+// badKind 判别属性字段不是字符串的变体
+type badKind struct {
+	Kind int `json:"kind"`
+}
+
+func (badKind) figure() {}
+
+// funcFigure 不是结构体的变体
+type funcFigure func()
+
+func (funcFigure) figure() {}
+
+var figureType = reflect.TypeOf((*figure)(nil)).Elem()
+
+// figureRegistry 注册了 figure 多态定义的注册表
+func figureRegistry(t *testing.T, variants map[string]reflect.Type) *TypeRegistry {
+	t.Helper()
+	registry := NewTypeRegistry()
+	if err := registry.RegisterUnion(figureType, "kind", variants); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	return registry
+}
+
+func TestRegisterUnionErrors(t *testing.T) {
+	squareType := reflect.TypeOf(square{})
+	tests := []struct {
+		name          string
+		iface         reflect.Type
+		discriminator string
+		variants      map[string]reflect.Type
+	}{
+		{"not an interface", squareType, "kind", map[string]reflect.Type{"square": squareType}},
+		{"empty discriminator", figureType, "", map[string]reflect.Type{"square": squareType}},
+		{"no variants", figureType, "kind", nil},
+		{"empty value", figureType, "kind", map[string]reflect.Type{"": squareType}},
+		{"nil variant", figureType, "kind", map[string]reflect.Type{"square": nil}},
+		{"not implemented", figureType, "kind", map[string]reflect.Type{"labeled": reflect.TypeOf(labeled{})}},
+		{"not a struct", figureType, "kind", map[string]reflect.Type{"func": reflect.TypeOf(funcFigure(nil))}},
+		{"non-string discriminator field", figureType, "kind", map[string]reflect.Type{"bad": reflect.TypeOf(badKind{})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewTypeRegistry().RegisterUnion(tt.iface, tt.discriminator, tt.variants); err == nil {
+				t.Error("RegisterUnion() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestTypeSchemaUnion(t *testing.T) {
+	registry := figureRegistry(t, map[string]reflect.Type{
+		"square":  reflect.TypeOf(square{}),
+		"labeled": reflect.TypeOf(&labeled{}),
+	})
+
+	schema, err := TypeSchema(figureType, WithTypeRegistry(registry))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+	if got, want := schema["discriminator"], map[string]interface{}{"propertyName": "kind"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("discriminator = %v, want %v", got, want)
+	}
+	if got, want := schema["description"], `One of "labeled", "square", selected by "kind"`; got != want {
+		t.Errorf("description = %v, want %v", got, want)
+	}
+
+	variants, _ := schema["oneOf"].([]interface{})
+	if len(variants) != 2 {
+		t.Fatalf("oneOf = %v, want 2 variants", schema["oneOf"])
+	}
+	// 变体按判别值排序
+	tests := []struct {
+		title    string
+		kind     map[string]interface{}
+		required []string
+	}{
+		{"labeled", map[string]interface{}{"type": "string", "const": "labeled", "description": "Figure kind"}, []string{"kind", "text"}},
+		{"square", map[string]interface{}{"type": "string", "const": "square"}, []string{"side", "kind"}},
+	}
+	for i, tt := range tests {
+		variant := variants[i].(map[string]interface{})
+		if variant["title"] != tt.title {
+			t.Errorf("oneOf[%d] title = %v, want %s", i, variant["title"], tt.title)
+		}
+		if got := variant["properties"].(map[string]interface{})["kind"]; !reflect.DeepEqual(got, tt.kind) {
+			t.Errorf("oneOf[%d] kind = %v, want %v", i, got, tt.kind)
+		}
+		if got := toStringSlice(variant["required"]); !reflect.DeepEqual(got, tt.required) {
+			t.Errorf("oneOf[%d] required = %v, want %v", i, got, tt.required)
+		}
+	}
+}
+
+func TestTypeSchemaRecursiveUnion(t *testing.T) {
+	registry := figureRegistry(t, map[string]reflect.Type{
+		"square": reflect.TypeOf(square{}),
+		"group":  reflect.TypeOf(group{}),
+	})
+
+	schema, err := TypeSchema(figureType, WithTypeRegistry(registry))
+	if err != nil {
+		t.Fatalf("TypeSchema() error = %v", err)
+	}
+	ref := map[string]interface{}{"$ref": "#/$defs/figure"}
+	if got := schema["$ref"]; got != ref["$ref"] {
+		t.Fatalf("schema = %v, want a reference to $defs/figure", schema)
+	}
+	def := schemaAt(t, schema, "$defs", "figure")
+	group := def["oneOf"].([]interface{})[0].(map[string]interface{})
+	if got := schemaAt(t, group, "properties", "items")["items"]; !reflect.DeepEqual(got, ref) {
+		t.Errorf("group items = %v, want %v", got, ref)
+	}
+}
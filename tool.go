@@ -0,0 +1,82 @@
+package nacosmcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"nacos-mcp-go/handler"
+	"nacos-mcp-go/scanner"
+)
+
+// AddTool 以类型安全的方式注册工具函数
+// 输入schema由 In 类型生成，In 必须生成object类型的schema（通常为结构体）；
+// Out 为结构体等object类型时同时生成 outputSchema，调用结果以 structuredContent 返回。
+// 参数的解码规则与 RegisterTool 注册的工具一致，时长字符串、注册的解码器和多态接口均可解码，调用时不经过反射
+func AddTool[In, Out any](s *Server, name, desc string, fn func(ctx context.Context, in In) (Out, error), opts ...ToolOption) error {
+	if fn == nil {
+		return fmt.Errorf("add tool %q failed: handler is nil", name)
+	}
+
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	inputSchema, err := scanner.TypeSchema(inType, scanner.WithTypeRegistry(s.typeRegistry))
+	if err != nil {
+		return fmt.Errorf("add tool %q failed: build input schema: %w", name, err)
+	}
+	if inputSchema["type"] != "object" {
+		return fmt.Errorf("add tool %q failed: input type %s must be a struct or map", name, inType)
+	}
+
+	sig, err := scanner.ParseSignature(reflect.TypeOf(fn))
+	if err != nil {
+		return fmt.Errorf("add tool %q failed: %w", name, err)
+	}
+
+	// 流式结果汇总为数组，不声明 outputSchema
+	var outputSchema map[string]interface{}
+	if sig.Stream == scanner.StreamNone {
+		outType := reflect.TypeOf((*Out)(nil)).Elem()
+		outputSchema, err = scanner.TypeSchema(outType, scanner.WithTypeRegistry(s.typeRegistry))
+		if err != nil {
+			return fmt.Errorf("add tool %q failed: build output schema: %w", name, err)
+		}
+		if outputSchema["type"] != "object" {
+			// MCP 要求 outputSchema 为object类型，其余返回值只以文本返回
+			outputSchema = nil
+		}
+	}
+
+	tool := Tool{
+		Name:         name,
+		Description:  desc,
+		InputSchema:  inputSchema,
+		OutputSchema: outputSchema,
+		Handler:      fn,
+		Invoke: func(ctx context.Context, arguments map[string]interface{}) (interface{}, error) {
+			in, err := decodeInput[In](arguments, s.typeRegistry)
+			if err != nil {
+				return nil, err
+			}
+			return fn(ctx, in)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&tool)
+	}
+
+	if err := s.addTools([]Tool{tool}, s.name); err != nil {
+		return fmt.Errorf("add tool failed: %w", err)
+	}
+	return nil
+}
+
+// decodeInput 将参数对象解码为输入类型，规则与反射调用工具函数时的参数解码一致
+func decodeInput[In any](arguments map[string]interface{}, registry *scanner.TypeRegistry) (In, error) {
+	var in In
+	value, err := handler.DecodeArguments(arguments, reflect.TypeOf((*In)(nil)).Elem(), registry)
+	if err != nil {
+		return in, err
+	}
+	return value.Interface().(In), nil
+}
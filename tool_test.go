@@ -0,0 +1,152 @@
+package nacosmcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"nacos-mcp-go/handler"
+)
+
+// retryInput 包含时长字段的输入
+type retryInput struct {
+	Delay time.Duration `json:"delay"`
+}
+
+// shape 注册为多态定义的接口
+type shape interface{ area() float64 }
+
+type circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c circle) area() float64 { return 3 * c.Radius * c.Radius }
+
+type rect struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+func (r *rect) area() float64 { return r.Width * r.Height }
+
+// shapeInput 包含多态接口字段的输入
+type shapeInput struct {
+	Shape shape `json:"shape"`
+}
+
+// cents 以 "12.34" 字符串传输的金额，模拟只能通过 WithTypeDecoder 解码的第三方类型
+type cents struct{ value int64 }
+
+// priceInput 包含第三方类型字段的输入
+type priceInput struct {
+	Price cents `json:"price"`
+}
+
+// invokeAdded 调用通过 AddTool 注册的工具
+func invokeAdded(t *testing.T, s *Server, name string, arguments map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	for _, tool := range s.GetTools() {
+		if tool.Name == name {
+			return tool.Invoke(context.Background(), arguments)
+		}
+	}
+	t.Fatalf("tool %q not registered", name)
+	return nil, nil
+}
+
+// assertIssuePath 检查错误为指定路径上的参数错误
+func assertIssuePath(t *testing.T, err error, path string) {
+	t.Helper()
+	var validationErr *handler.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *handler.ValidationError", err)
+	}
+	if len(validationErr.Issues) != 1 || validationErr.Issues[0].Path != path {
+		t.Errorf("issues = %+v, want one issue at %s", validationErr.Issues, path)
+	}
+}
+
+func TestAddToolDecodesDuration(t *testing.T) {
+	s := NewServer("retry")
+	err := AddTool(s, "retry", "Retry later", func(ctx context.Context, in retryInput) (string, error) {
+		return in.Delay.String(), nil
+	})
+	if err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
+
+	got, err := invokeAdded(t, s, "retry", map[string]interface{}{"delay": "1h30m"})
+	if err != nil || got != "1h30m0s" {
+		t.Errorf("Invoke() = %v, %v, want 1h30m0s", got, err)
+	}
+
+	_, err = invokeAdded(t, s, "retry", map[string]interface{}{"delay": "soon"})
+	assertIssuePath(t, err, "/delay")
+}
+
+func TestAddToolDecodesUnion(t *testing.T) {
+	s := NewServer("shapes")
+	if err := s.RegisterUnion((*shape)(nil), "kind", map[string]interface{}{
+		"circle": circle{},
+		"rect":   &rect{},
+	}); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	err := AddTool(s, "area", "Area of a shape", func(ctx context.Context, in shapeInput) (float64, error) {
+		return in.Shape.area(), nil
+	})
+	if err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
+
+	tests := []struct {
+		shape map[string]interface{}
+		want  float64
+	}{
+		{map[string]interface{}{"kind": "circle", "radius": json.Number("2")}, 12},
+		{map[string]interface{}{"kind": "rect", "width": json.Number("2"), "height": json.Number("3")}, 6},
+	}
+	for _, tt := range tests {
+		got, err := invokeAdded(t, s, "area", map[string]interface{}{"shape": tt.shape})
+		if err != nil || got != tt.want {
+			t.Errorf("Invoke(%v) = %v, %v, want %v", tt.shape, got, err, tt.want)
+		}
+	}
+
+	_, err = invokeAdded(t, s, "area", map[string]interface{}{"shape": map[string]interface{}{"kind": "triangle"}})
+	assertIssuePath(t, err, "/shape/kind")
+}
+
+func TestAddToolUsesTypeDecoder(t *testing.T) {
+	s := NewServer("prices",
+		WithTypeSchema(cents{}, map[string]interface{}{"type": "string", "pattern": `^\d+\.\d{2}$`}),
+		WithTypeDecoder(cents{}, func(data json.RawMessage) (interface{}, error) {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return nil, err
+			}
+			var whole, frac int64
+			if _, err := fmt.Sscanf(s, "%d.%02d", &whole, &frac); err != nil {
+				return nil, fmt.Errorf("malformed amount %q", s)
+			}
+			return cents{value: whole*100 + frac}, nil
+		}),
+	)
+	err := AddTool(s, "charge", "Charge a price", func(ctx context.Context, in priceInput) (int64, error) {
+		return in.Price.value, nil
+	})
+	if err != nil {
+		t.Fatalf("AddTool() error = %v", err)
+	}
+
+	got, err := invokeAdded(t, s, "charge", map[string]interface{}{"price": "12.34"})
+	if err != nil || got != int64(1234) {
+		t.Errorf("Invoke() = %v, %v, want 1234", got, err)
+	}
+
+	_, err = invokeAdded(t, s, "charge", map[string]interface{}{"price": "free"})
+	assertIssuePath(t, err, "/price")
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName 创建Tracer时使用的instrumentation名称
+const InstrumentationName = "nacos-mcp-go"
+
+// propagator W3C Trace Context 和 Baggage 传播器，不依赖全局设置
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Tracer 返回 TracerProvider 创建的Tracer，tp 为空时使用 otel 的全局 TracerProvider
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(InstrumentationName)
+}
+
+// Extract 从载体中提取上游的链路上下文（traceparent、tracestate、baggage）
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return propagator.Extract(ctx, carrier)
+}
+
+// Inject 将上下文中的链路信息写入载体
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	propagator.Inject(ctx, carrier)
+}
+
+// HasTraceParent 判断载体中是否带有 traceparent
+func HasTraceParent(carrier propagation.TextMapCarrier) bool {
+	return carrier.Get("traceparent") != ""
+}
+
+// MetaCarrier 以MCP请求参数中的 _meta 对象作为传播载体
+type MetaCarrier map[string]interface{}
+
+// Get 实现 propagation.TextMapCarrier 接口
+func (c MetaCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+// Set 实现 propagation.TextMapCarrier 接口
+func (c MetaCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys 实现 propagation.TextMapCarrier 接口
+func (c MetaCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Transport 为每个HTTP请求创建客户端span并注入 traceparent 请求头的 http.RoundTripper
+type Transport struct {
+	Base           http.RoundTripper    // 实际发送请求的 RoundTripper，为空时使用 http.DefaultTransport
+	TracerProvider trace.TracerProvider // 为空时使用全局 TracerProvider
+}
+
+// NewTransport 包装 base 创建Transport
+func NewTransport(base http.RoundTripper, tp trace.TracerProvider) *Transport {
+	return &Transport{Base: base, TracerProvider: tp}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := Tracer(t.TracerProvider).Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(requestAttributes(req)...),
+	)
+	defer span.End()
+
+	// RoundTripper 不能修改原请求，复制后注入链路请求头
+	req = req.Clone(ctx)
+	Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetAttributes(attribute.String("error.type", strconv.Itoa(resp.StatusCode)))
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// requestAttributes 返回客户端span的HTTP属性，URL中的查询参数可能包含令牌，不记录
+func requestAttributes(req *http.Request) []attribute.KeyValue {
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", u.String()),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+	if port := urlPort(req.URL); port > 0 {
+		attrs = append(attrs, attribute.Int("server.port", port))
+	}
+	return attrs
+}
+
+// urlPort 返回URL的端口，未指定时按协议取默认端口
+func urlPort(u *url.URL) int {
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"context"
+	"time"
+)
+
 // Protocol MCP协议类型
 type Protocol string
 
@@ -9,12 +14,88 @@ const (
 	ProtocolStreamHTTP Protocol = "streamable-http" // 流式HTTP
 )
 
+// OutputFormat 工具返回值转换为文本内容的格式
+type OutputFormat string
+
+const (
+	OutputText        OutputFormat = "text"         // 按 %v 输出
+	OutputJSON        OutputFormat = "json"         // 缩进的JSON
+	OutputJSONCompact OutputFormat = "json-compact" // 紧凑的JSON
+	OutputMarkdown    OutputFormat = "markdown"     // 结构体切片输出为Markdown表格
+	OutputYAML        OutputFormat = "yaml"         // YAML
+)
+
+// ResultFormatter 将工具返回值格式化为文本内容
+type ResultFormatter func(result interface{}) (string, error)
+
 // Tool MCP工具定义
 type Tool struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	InputSchema map[string]interface{} `json:"inputSchema"`
-	Handler     interface{}            `json:"-"`
+	Name           string                 `json:"name"`
+	ScannedName    string                 `json:"-"`               // 扫描得到的工具名，清单以此为键，重名添加前缀时为添加前缀后的名称，为空时与 Name 相同
+	Title          string                 `json:"title,omitempty"` // 展示给用户的工具名称
+	Description    string                 `json:"description"`
+	InputSchema    map[string]interface{} `json:"inputSchema"`
+	OutputSchema   map[string]interface{} `json:"outputSchema,omitempty"` // 结构化返回值的schema，为空时只返回文本内容
+	Handler        interface{}            `json:"-"`
+	ParamNames     []string               `json:"-"` // 函数各参数在 InputSchema 中的名称，单个结构体参数时为空
+	Annotations    *ToolAnnotations       `json:"annotations,omitempty"`
+	OutputFormat   OutputFormat           `json:"-"` // 返回值的文本格式，为空时使用服务器默认格式
+	Formatter      ResultFormatter        `json:"-"` // 自定义格式化函数，优先于 OutputFormat
+	Invoke         InvokeFunc             `json:"-"` // 类型安全的调用入口，设置后不再通过反射调用 Handler
+	Middleware     []ToolMiddleware       `json:"-"` // 工具级中间件，在服务器级中间件之内执行
+	Auth           *AuthRule              `json:"-"` // 访问规则，为nil时已认证的调用方均可调用
+	Timeout        time.Duration          `json:"-"` // 调用超时，为0时使用服务器默认超时
+	MaxConcurrency int                    `json:"-"` // 同时执行的最大调用数，为0时不限制
+	RateLimit      *RateLimit             `json:"-"` // 所有调用方共享的限流，为nil时不限制
+	CacheTTL       time.Duration          `json:"-"` // 结果缓存时长，为0时不缓存
+}
+
+// RateLimit 令牌桶限流配置
+type RateLimit struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶容量，即允许的瞬时突发调用数
+}
+
+// AuthRule 工具的访问规则
+type AuthRule struct {
+	Principals []string // 允许调用的主体，为空时不限制主体
+	Scopes     []string // 调用方必须具备的全部 scope
+}
+
+// ToolAnnotations 工具行为提示，客户端据此决定是否需要用户确认
+// 各字段为nil时表示未声明，客户端按MCP规范的默认值处理
+type ToolAnnotations struct {
+	ReadOnlyHint    *bool `json:"readOnlyHint,omitempty" yaml:"readOnlyHint,omitempty"`       // 工具不修改环境
+	DestructiveHint *bool `json:"destructiveHint,omitempty" yaml:"destructiveHint,omitempty"` // 工具可能执行破坏性更新
+	IdempotentHint  *bool `json:"idempotentHint,omitempty" yaml:"idempotentHint,omitempty"`   // 相同参数重复调用没有额外影响
+	OpenWorldHint   *bool `json:"openWorldHint,omitempty" yaml:"openWorldHint,omitempty"`     // 工具与外部系统交互
+}
+
+// InvokeFunc 以校验后的参数对象调用工具
+type InvokeFunc func(ctx context.Context, arguments map[string]interface{}) (interface{}, error)
+
+// ToolRequest 一次工具调用
+type ToolRequest struct {
+	Tool      *Tool                  // 被调用的工具
+	Arguments map[string]interface{} // 校验并填充默认值后的参数，中间件可在调用下一环节前修改
+	Session   string                 // 服务端签发的 Mcp-Session-Id，请求未携带有效会话时为空
+}
+
+// ToolHandlerFunc 执行工具调用，返回工具函数的返回值（流式结果已汇总为切片）
+type ToolHandlerFunc func(ctx context.Context, req *ToolRequest) (interface{}, error)
+
+// ToolMiddleware 包装工具调用，用于鉴权、日志、指标、重试、缓存等横切逻辑
+type ToolMiddleware func(next ToolHandlerFunc) ToolHandlerFunc
+
+// SecurityScheme 调用MCP服务器时使用的认证方式，注册到Nacos供客户端发现
+type SecurityScheme struct {
+	ID                   string   `json:"id"`
+	Type                 string   `json:"type"`                           // http、apiKey 或 oauth2
+	Scheme               string   `json:"scheme,omitempty"`               // type 为 http 时的认证方案，如 bearer
+	In                   string   `json:"in,omitempty"`                   // type 为 apiKey 时凭证所在位置，如 header
+	Name                 string   `json:"name,omitempty"`                 // type 为 apiKey 时携带凭证的请求头
+	ResourceMetadata     string   `json:"resourceMetadata,omitempty"`     // type 为 oauth2 时受保护资源元数据的地址
+	AuthorizationServers []string `json:"authorizationServers,omitempty"` // type 为 oauth2 时签发令牌的授权服务器
 }
 
 // ServerInterface MCP服务器接口
@@ -26,5 +107,6 @@ type ServerInterface interface {
 	GetProtocol() Protocol
 	GetTools() []Tool
 	GetMetadata() map[string]string
+	GetSecuritySchemes() []SecurityScheme
 	IsRunning() bool
 }